@@ -0,0 +1,371 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/nervosnetwork/ckb-sdk-go/indexer"
+	"github.com/nervosnetwork/ckb-sdk-go/rpc"
+	"github.com/nervosnetwork/ckb-sdk-go/transaction"
+	"github.com/nervosnetwork/ckb-sdk-go/types"
+	"github.com/nervosnetwork/ckb-sdk-go/utils"
+	"github.com/ququzone/ckb-udt-cli/config"
+	"github.com/spf13/cobra"
+)
+
+const acpPubKeyHashLen = 20
+
+// ACPMinimum is the optional minimum payment encoded in anyone-can-pay lock args.
+// A nil field means the lock does not set that minimum.
+type ACPMinimum struct {
+	CKB *big.Int
+	UDT *big.Int
+}
+
+// ACPArgs builds anyone-can-pay lock args from a pubkey hash and optional minimum exponents.
+// The UDT minimum can only be encoded together with a CKB minimum.
+func ACPArgs(pubKeyHash []byte, minCKB *uint8, minUDT *uint8) ([]byte, error) {
+	if minUDT != nil && minCKB == nil {
+		return nil, errors.New("UDT minimum requires CKB minimum")
+	}
+	args := make([]byte, 0, acpPubKeyHashLen+2)
+	args = append(args, pubKeyHash...)
+	if minCKB != nil {
+		args = append(args, *minCKB)
+	}
+	if minUDT != nil {
+		args = append(args, *minUDT)
+	}
+	return args, nil
+}
+
+func ParseACPArgs(args []byte) (*ACPMinimum, error) {
+	if len(args) < acpPubKeyHashLen || len(args) > acpPubKeyHashLen+2 {
+		return nil, errors.New("invalid anyone can pay lock args length")
+	}
+	min := &ACPMinimum{}
+	if len(args) > acpPubKeyHashLen {
+		min.CKB = pow10(args[acpPubKeyHashLen])
+	}
+	if len(args) > acpPubKeyHashLen+1 {
+		min.UDT = pow10(args[acpPubKeyHashLen+1])
+	}
+	return min, nil
+}
+
+func pow10(exponent uint8) *big.Int {
+	return big.NewInt(0).Exp(big.NewInt(10), big.NewInt(int64(exponent)), nil)
+}
+
+func describeACPMinimum(min *ACPMinimum) string {
+	var parts []string
+	if min.CKB != nil {
+		parts = append(parts, fmt.Sprintf("%s shannons", min.CKB.String()))
+	}
+	if min.UDT != nil {
+		parts = append(parts, fmt.Sprintf("%s tokens", min.UDT.String()))
+	}
+	return strings.Join(parts, " or ")
+}
+
+func findACPCell(client rpc.Client, c *config.Config, pubKeyHash []byte, uuid []byte, filter *OutPointFilter) (*indexer.LiveCell, error) {
+	searchKey := &indexer.SearchKey{
+		Script: &types.Script{
+			CodeHash: types.HexToHash(c.ACP.Script.CodeHash),
+			HashType: types.ScriptHashType(c.ACP.Script.HashType),
+			Args:     pubKeyHash,
+		},
+		ScriptType: "lock",
+	}
+	cells, err := CollectUDT(client, c, searchKey, "asc", 1000, "", uuid, big.NewInt(0), filter)
+	if err != nil {
+		return nil, err
+	}
+	if len(cells.LiveCells) == 0 {
+		return nil, errors.New("can't find anyone can pay cell")
+	}
+	return cells.LiveCells[0], nil
+}
+
+func newACPTransaction(c *config.Config, scripts *utils.SystemScripts) *types.Transaction {
+	tx := transaction.NewSecp256k1SingleSigTx(scripts)
+	for _, dep := range c.UDT.Deps {
+		tx.CellDeps = append(tx.CellDeps, &types.CellDep{
+			OutPoint: &types.OutPoint{
+				TxHash: types.HexToHash(dep.TxHash),
+				Index:  dep.Index,
+			},
+			DepType: types.DepType(dep.DepType),
+		})
+	}
+	for _, dep := range c.ACP.Deps {
+		tx.CellDeps = append(tx.CellDeps, &types.CellDep{
+			OutPoint: &types.OutPoint{
+				TxHash: types.HexToHash(dep.TxHash),
+				Index:  dep.Index,
+			},
+			DepType: types.DepType(dep.DepType),
+		})
+	}
+	return tx
+}
+
+var (
+	acpConf     *string
+	acpKey      *KeyOptions
+	acpUUID     *string
+	acpStandard *string
+	acpCapacity *uint64
+
+	acpDepositSend  *SendOptions
+	acpWithdrawSend *SendOptions
+)
+
+var acpCmd = &cobra.Command{
+	Use:   "acp",
+	Short: "Manage anyone can pay cell",
+	Long:  `Manage CKB capacity of anyone can pay cell.`,
+}
+
+var acpDepositCmd = &cobra.Command{
+	Use:   "deposit-ckb",
+	Short: "Deposit CKB into anyone can pay cell",
+	Long:  `Deposit CKB from secp256k1 cells into anyone can pay cell, keeping its sUDT amount.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		c, err := config.Init(*acpConf)
+		if err != nil {
+			Fatalf("load config error: %v", err)
+		}
+		if err = c.SelectStandard(*acpStandard); err != nil {
+			Fatalf("token standard error: %v", err)
+		}
+
+		client, err := Dial(c)
+		if err != nil {
+			Fatalf("create rpc client error: %v", err)
+		}
+
+		key, err := acpKey.Load()
+		if err != nil {
+			Fatalf("import private key error: %v", err)
+		}
+
+		scripts, err := utils.NewSystemScripts(client)
+		if err != nil {
+			Fatalf("load system script error: %v", err)
+		}
+
+		change, err := key.Script(scripts)
+		if err != nil {
+			Fatalf("load system script error: %v", err)
+		}
+
+		filter, err := NewOutPointFilter(nil, nil)
+		if err != nil {
+			Fatalf("outpoint filter error: %v", err)
+		}
+		store, err := OpenReservations(client, c, filter)
+		if err != nil {
+			Fatalf("open reservation store error: %v", err)
+		}
+		acpCell, err := findACPCell(client, c, change.Args, ParseUUID(*acpUUID), filter)
+		if err != nil {
+			Fatalf("find anyone can pay cell error: %v", err)
+		}
+
+		capacity := *acpCapacity
+		fee := uint64(1000)
+		searchKey := &indexer.SearchKey{
+			Script:     change,
+			ScriptType: "lock",
+		}
+		cells, err := SelectCapacity(client, searchKey, capacity+fee+6100000000, &oldestFirstSelector{}, filter)
+		if err != nil {
+			Fatalf("collect cell error: %v", err)
+		}
+		if cells.Capacity < capacity+fee {
+			Fatalf("insufficient capacity: %d < %d", cells.Capacity, capacity+fee)
+		}
+
+		tx := newACPTransaction(c, scripts)
+		tx.Outputs = append(tx.Outputs, &types.CellOutput{
+			Capacity: acpCell.Output.Capacity + capacity,
+			Lock:     acpCell.Output.Lock,
+			Type:     acpCell.Output.Type,
+		})
+		tx.OutputsData = append(tx.OutputsData, acpCell.OutputData)
+		if cells.Capacity-capacity-fee >= 6100000000 {
+			tx.Outputs = append(tx.Outputs, &types.CellOutput{
+				Capacity: cells.Capacity - capacity - fee,
+				Lock:     change,
+			})
+			tx.OutputsData = append(tx.OutputsData, []byte{})
+		} else {
+			tx.Outputs[0].Capacity = tx.Outputs[0].Capacity + cells.Capacity - capacity - fee
+		}
+
+		acpGroup, acpWitnessArgs, err := AddLiveCellInputs(tx, []*indexer.LiveCell{acpCell})
+		if err != nil {
+			Fatalf("add inputs to transaction error: %v", err)
+		}
+		group, witnessArgs, err := AddLiveCellInputs(tx, cells.LiveCells)
+		if err != nil {
+			Fatalf("add inputs to transaction error: %v", err)
+		}
+
+		if err = ValidateTransaction(client, c, tx); err != nil {
+			Fatalf("validate transaction error: %v", err)
+		}
+
+		err = transaction.SingleSignTransaction(tx, acpGroup, acpWitnessArgs, key)
+		if err != nil {
+			Fatalf("sign transaction error: %v", err)
+		}
+		err = transaction.SingleSignTransaction(tx, group, witnessArgs, key)
+		if err != nil {
+			Fatalf("sign transaction error: %v", err)
+		}
+
+		acpDepositSend.VerifyTransaction(client, c, tx)
+		err = store.Reserve(tx)
+		if err != nil {
+			Fatalf("reserve inputs error: %v", err)
+		}
+		store.Unlock()
+
+		hash, err := client.SendTransaction(context.Background(), tx)
+		if err != nil {
+			_ = store.Release(tx)
+			Fatalf("send transaction error: %s", ExplainScriptError(client, c, tx, err))
+		}
+		if err = store.Confirm(tx, *hash); err != nil {
+			fmt.Printf("record reservation error: %v\n", err)
+		}
+
+		fmt.Printf("deposit CKB transaction hash: %s, cell capacity: %d\n", hash.String(), tx.Outputs[0].Capacity)
+		acpDepositSend.WaitCommitted(client, hash)
+	},
+}
+
+var acpWithdrawCmd = &cobra.Command{
+	Use:   "withdraw-ckb",
+	Short: "Withdraw CKB from anyone can pay cell",
+	Long:  `Withdraw CKB from anyone can pay cell to secp256k1 cell, keeping its sUDT amount and occupied capacity.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		c, err := config.Init(*acpConf)
+		if err != nil {
+			Fatalf("load config error: %v", err)
+		}
+		if err = c.SelectStandard(*acpStandard); err != nil {
+			Fatalf("token standard error: %v", err)
+		}
+
+		client, err := Dial(c)
+		if err != nil {
+			Fatalf("create rpc client error: %v", err)
+		}
+
+		key, err := acpKey.Load()
+		if err != nil {
+			Fatalf("import private key error: %v", err)
+		}
+
+		scripts, err := utils.NewSystemScripts(client)
+		if err != nil {
+			Fatalf("load system script error: %v", err)
+		}
+
+		change, err := key.Script(scripts)
+		if err != nil {
+			Fatalf("load system script error: %v", err)
+		}
+
+		filter, err := NewOutPointFilter(nil, nil)
+		if err != nil {
+			Fatalf("outpoint filter error: %v", err)
+		}
+		store, err := OpenReservations(client, c, filter)
+		if err != nil {
+			Fatalf("open reservation store error: %v", err)
+		}
+		acpCell, err := findACPCell(client, c, change.Args, ParseUUID(*acpUUID), filter)
+		if err != nil {
+			Fatalf("find anyone can pay cell error: %v", err)
+		}
+
+		capacity := *acpCapacity
+		fee := uint64(1000)
+		occupied := acpCell.Output.OccupiedCapacity(acpCell.OutputData) * 100000000
+		if capacity > acpCell.Output.Capacity || acpCell.Output.Capacity-capacity < occupied {
+			Fatalf("withdraw exceeds free capacity: cell capacity %d, occupied %d", acpCell.Output.Capacity, occupied)
+		}
+		if capacity < fee+6100000000 {
+			Fatalf("withdraw capacity must be at least %d", fee+6100000000)
+		}
+
+		tx := newACPTransaction(c, scripts)
+		tx.Outputs = append(tx.Outputs, &types.CellOutput{
+			Capacity: acpCell.Output.Capacity - capacity,
+			Lock:     acpCell.Output.Lock,
+			Type:     acpCell.Output.Type,
+		})
+		tx.OutputsData = append(tx.OutputsData, acpCell.OutputData)
+		tx.Outputs = append(tx.Outputs, &types.CellOutput{
+			Capacity: capacity - fee,
+			Lock:     change,
+		})
+		tx.OutputsData = append(tx.OutputsData, []byte{})
+
+		group, witnessArgs, err := AddLiveCellInputs(tx, []*indexer.LiveCell{acpCell})
+		if err != nil {
+			Fatalf("add inputs to transaction error: %v", err)
+		}
+
+		if err = ValidateTransaction(client, c, tx); err != nil {
+			Fatalf("validate transaction error: %v", err)
+		}
+
+		err = transaction.SingleSignTransaction(tx, group, witnessArgs, key)
+		if err != nil {
+			Fatalf("sign transaction error: %v", err)
+		}
+
+		acpWithdrawSend.VerifyTransaction(client, c, tx)
+		err = store.Reserve(tx)
+		if err != nil {
+			Fatalf("reserve inputs error: %v", err)
+		}
+		store.Unlock()
+
+		hash, err := client.SendTransaction(context.Background(), tx)
+		if err != nil {
+			_ = store.Release(tx)
+			Fatalf("send transaction error: %s", ExplainScriptError(client, c, tx, err))
+		}
+		if err = store.Confirm(tx, *hash); err != nil {
+			fmt.Printf("record reservation error: %v\n", err)
+		}
+
+		fmt.Printf("withdraw CKB transaction hash: %s, cell capacity: %d\n", hash.String(), tx.Outputs[0].Capacity)
+		acpWithdrawSend.WaitCommitted(client, hash)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(acpCmd)
+	acpCmd.AddCommand(acpDepositCmd)
+	acpCmd.AddCommand(acpWithdrawCmd)
+
+	acpConf = acpCmd.PersistentFlags().StringP("config", "c", "config.yaml", "Config file")
+	acpKey = AddKeyFlags(acpCmd.PersistentFlags(), "Private key")
+	acpUUID = acpCmd.PersistentFlags().StringP("uuid", "u", "", "UDT uuid")
+	acpStandard = acpCmd.PersistentFlags().String("standard", StandardSUDT, "Token standard: sudt or xudt")
+	acpCapacity = acpCmd.PersistentFlags().Uint64("capacity", 0, "Capacity in shannons")
+	acpDepositSend = AddSendFlags(acpDepositCmd)
+	acpWithdrawSend = AddSendFlags(acpWithdrawCmd)
+	_ = acpCmd.MarkPersistentFlagRequired("uuid")
+	_ = acpCmd.MarkPersistentFlagRequired("capacity")
+}
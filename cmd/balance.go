@@ -4,7 +4,6 @@ import (
 	"fmt"
 	"github.com/nervosnetwork/ckb-sdk-go/address"
 	"github.com/nervosnetwork/ckb-sdk-go/indexer"
-	"github.com/nervosnetwork/ckb-sdk-go/rpc"
 	"github.com/nervosnetwork/ckb-sdk-go/types"
 	"github.com/ququzone/ckb-udt-cli/config"
 	"github.com/spf13/cobra"
@@ -12,22 +11,27 @@ import (
 )
 
 var (
-	balanceConf *string
-	balanceUUID *string
-	balanceAddr *string
+	balanceConf     *string
+	balanceUUID     *string
+	balanceAddr     *string
+	balanceRaw      *bool
+	balanceStandard *string
 )
 
 var balanceCmd = &cobra.Command{
 	Use:   "balance",
-	Short: "Query sUDT balance",
-	Long:  `Query sUDT balance by address.`,
+	Short: "Query sUDT or xUDT balance",
+	Long:  `Query sUDT or xUDT balance by address.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		c, err := config.Init(*balanceConf)
 		if err != nil {
 			Fatalf("load config error: %v", err)
 		}
+		if err = c.SelectStandard(*balanceStandard); err != nil {
+			Fatalf("token standard error: %v", err)
+		}
 
-		client, err := rpc.DialWithIndexer(c.RPC, c.CkbIndexer)
+		client, err := Dial(c)
 		if err != nil {
 			Fatalf("create rpc client error: %v", err)
 		}
@@ -40,12 +44,23 @@ var balanceCmd = &cobra.Command{
 			Script:     addr.Script,
 			ScriptType: "lock",
 		}
-		cells, err := CollectUDT(client, c, searchKey, "asc", 1000, "", types.HexToHash(*balanceUUID).Bytes(), nil)
+		cells, err := CollectUDT(client, c, searchKey, "asc", 1000, "", ParseUUID(*balanceUUID), nil, nil)
 		if err != nil {
 			Fatalf("collect cell error: %v", err)
 		}
 
-		fmt.Printf("Address %s amount: %s\n", *balanceAddr, cells.Options["total"].(*big.Int).String())
+		total := cells.Options["total"].(*big.Int)
+		if !*balanceRaw && *balanceStandard == StandardSUDT && c.Info.Script.CodeHash != "" {
+			info, _, err := FindTokenInfo(client, c, types.HexToHash(*balanceUUID))
+			if err != nil {
+				Fatalf("query info cell error: %v", err)
+			}
+			if info != nil {
+				fmt.Printf("Address %s amount: %s %s\n", *balanceAddr, FormatAmount(total, info.Decimals), info.Symbol)
+				return
+			}
+		}
+		fmt.Printf("Address %s amount: %s\n", *balanceAddr, total.String())
 	},
 }
 
@@ -55,6 +70,8 @@ func init() {
 	balanceConf = balanceCmd.Flags().StringP("config", "c", "config.yaml", "Config file")
 	balanceUUID = balanceCmd.Flags().StringP("uuid", "u", "", "UDT uuid")
 	balanceAddr = balanceCmd.Flags().StringP("address", "a", "", "Address")
+	balanceRaw = balanceCmd.Flags().Bool("raw", false, "Print the raw amount even when the token has an info cell")
+	balanceStandard = balanceCmd.Flags().String("standard", StandardSUDT, "Token standard: sudt or xudt")
 	_ = balanceCmd.MarkFlagRequired("uuid")
 	_ = balanceCmd.MarkFlagRequired("address")
 }
@@ -0,0 +1,206 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/nervosnetwork/ckb-sdk-go/indexer"
+	"github.com/nervosnetwork/ckb-sdk-go/transaction"
+	"github.com/nervosnetwork/ckb-sdk-go/types"
+	"github.com/nervosnetwork/ckb-sdk-go/utils"
+	"github.com/ququzone/ckb-udt-cli/config"
+	"github.com/spf13/cobra"
+)
+
+var (
+	bumpFeeConf *string
+	bumpFeeKey  *KeyOptions
+	bumpFeeFee  *uint64
+	bumpFeeSend *SendOptions
+)
+
+var bumpFeeCmd = &cobra.Command{
+	Use:   "bump-fee TXHASH",
+	Short: "Replace a pending transaction with a higher fee",
+	Long:  `Rebuild a pending transaction sent by this tool with the same inputs and outputs, paying a higher fee from its change output, and submit it as a replacement.`,
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		c, err := config.Init(*bumpFeeConf)
+		if err != nil {
+			Fatalf("load config error: %v", err)
+		}
+
+		client, err := Dial(c)
+		if err != nil {
+			Fatalf("create rpc client error: %v", err)
+		}
+
+		key, err := bumpFeeKey.Load()
+		if err != nil {
+			Fatalf("import private key error: %v", err)
+		}
+
+		scripts, err := utils.NewSystemScripts(client)
+		if err != nil {
+			Fatalf("load system script error: %v", err)
+		}
+
+		change, err := key.Script(scripts)
+		if err != nil {
+			Fatalf("load system script error: %v", err)
+		}
+
+		hash := types.HexToHash(args[0])
+		store, err := NewReservationStore(c)
+		if err != nil {
+			Fatalf("open reservation store error: %v", err)
+		}
+		if err = store.Lock(); err != nil {
+			Fatalf("open reservation store error: %v", err)
+		}
+		if _, err = store.Prune(client); err != nil {
+			Fatalf("open reservation store error: %v", err)
+		}
+		if !store.Sent(hash) {
+			Fatalf("transaction %s is not a pending transaction sent by this tool", hash.String())
+		}
+		if store.SpentOutputs(hash) {
+			Fatalf("outputs of transaction %s are spent by other pending transactions, replacing it would invalidate them", hash.String())
+		}
+
+		original, err := client.GetTransaction(context.Background(), hash)
+		if err != nil {
+			Fatalf("query transaction error: %v", err)
+		}
+		status := original.TxStatus.Status
+		if status != types.TransactionStatusPending && status != types.TransactionStatusProposed {
+			if status == "" {
+				status = txStatusUnknown
+			}
+			Fatalf("transaction %s is %s, only pending transactions can be replaced", hash.String(), status)
+		}
+		origin := original.Transaction
+
+		// resolve inputs, cells owned by the key are re-signed and other anyone can pay cells are unlocked by their outputs
+		var inputCapacity uint64
+		var owned []*indexer.LiveCell
+		var unsigned []*types.CellInput
+		for _, input := range origin.Inputs {
+			previous, err := client.GetTransaction(context.Background(), input.PreviousOutput.TxHash)
+			if err != nil {
+				Fatalf("query input transaction error: %v", err)
+			}
+			if previous.Transaction == nil || int(input.PreviousOutput.Index) >= len(previous.Transaction.Outputs) {
+				Fatalf("can't resolve input %s", OutPointString(input.PreviousOutput))
+			}
+			output := previous.Transaction.Outputs[input.PreviousOutput.Index]
+			inputCapacity += output.Capacity
+			lock := output.Lock
+			switch {
+			case lock.Equals(change), lock.CodeHash.String() == c.ACP.Script.CodeHash && bytes.HasPrefix(lock.Args, change.Args):
+				owned = append(owned, &indexer.LiveCell{
+					OutPoint:   input.PreviousOutput,
+					Output:     output,
+					OutputData: previous.Transaction.OutputsData[input.PreviousOutput.Index],
+				})
+			case lock.CodeHash.String() == c.ACP.Script.CodeHash:
+				unsigned = append(unsigned, input)
+			default:
+				Fatalf("input %s is locked by a script this key can't sign", OutPointString(input.PreviousOutput))
+			}
+		}
+		var outputCapacity uint64
+		for _, output := range origin.Outputs {
+			outputCapacity += output.Capacity
+		}
+		if inputCapacity < outputCapacity {
+			Fatalf("invalid transaction, outputs capacity %d exceeds inputs capacity %d", outputCapacity, inputCapacity)
+		}
+		oldFee := inputCapacity - outputCapacity
+
+		fee := *bumpFeeFee
+		if fee == 0 {
+			fee = oldFee * 2
+		}
+		if fee <= oldFee {
+			Fatalf("new fee %d must be higher than the current fee %d", fee, oldFee)
+		}
+		delta := fee - oldFee
+
+		// the fee comes from the plain capacity change cell of the key
+		changeIndex := -1
+		for i, output := range origin.Outputs {
+			if output.Lock.Equals(change) && output.Type == nil && len(origin.OutputsData[i]) == 0 {
+				changeIndex = i
+			}
+		}
+		if changeIndex < 0 {
+			Fatalf("transaction %s has no capacity change output to pay the fee from", hash.String())
+		}
+		if origin.Outputs[changeIndex].Capacity < delta+6100000000 {
+			Fatalf("change output capacity %d can't pay %d more fee", origin.Outputs[changeIndex].Capacity, delta)
+		}
+
+		tx := transaction.NewSecp256k1SingleSigTx(scripts)
+		tx.CellDeps = origin.CellDeps
+		tx.HeaderDeps = origin.HeaderDeps
+		for i, output := range origin.Outputs {
+			tx.Outputs = append(tx.Outputs, &types.CellOutput{
+				Capacity: output.Capacity,
+				Lock:     output.Lock,
+				Type:     output.Type,
+			})
+			tx.OutputsData = append(tx.OutputsData, origin.OutputsData[i])
+		}
+		tx.Outputs[changeIndex].Capacity -= delta
+
+		for _, input := range unsigned {
+			tx.Inputs = append(tx.Inputs, input)
+			tx.Witnesses = append(tx.Witnesses, []byte{})
+		}
+		var groups [][]int
+		var witnesses []*types.WitnessArgs
+		for _, cells := range GroupLiveCellsByLock(owned) {
+			group, witnessArgs, err := AddLiveCellInputs(tx, cells)
+			if err != nil {
+				Fatalf("add inputs to transaction error: %v", err)
+			}
+			groups = append(groups, group)
+			witnesses = append(witnesses, witnessArgs)
+		}
+
+		if err = ValidateTransaction(client, c, tx); err != nil {
+			Fatalf("validate transaction error: %v", err)
+		}
+
+		for i, group := range groups {
+			err = transaction.SingleSignTransaction(tx, group, witnesses[i], key)
+			if err != nil {
+				Fatalf("sign transaction error: %v", err)
+			}
+		}
+		bumpFeeSend.VerifyTransaction(client, c, tx)
+		store.Unlock()
+
+		replacement, err := client.SendTransaction(context.Background(), tx)
+		if err != nil {
+			Fatalf("node rejected the replacement of %s with fee %d: %s\noriginal transaction is still pending", hash.String(), fee, ExplainScriptError(client, c, tx, err))
+		}
+		if err = store.Replace(hash, tx, *replacement); err != nil {
+			fmt.Printf("record reservation error: %v\n", err)
+		}
+
+		fmt.Printf("replaced transaction %s with %s, fee %d -> %d\n", hash.String(), replacement.String(), oldFee, fee)
+		bumpFeeSend.WaitCommitted(client, replacement)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(bumpFeeCmd)
+
+	bumpFeeConf = bumpFeeCmd.Flags().StringP("config", "c", "config.yaml", "Config file")
+	bumpFeeKey = AddKeyFlags(bumpFeeCmd.Flags(), "Private key that signed the transaction")
+	bumpFeeFee = bumpFeeCmd.Flags().Uint64("fee", 0, "New fee in shannons (default twice the current fee)")
+	bumpFeeSend = AddSendFlags(bumpFeeCmd)
+}
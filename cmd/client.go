@@ -0,0 +1,267 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/nervosnetwork/ckb-sdk-go/indexer"
+	"github.com/nervosnetwork/ckb-sdk-go/rpc"
+	"github.com/nervosnetwork/ckb-sdk-go/types"
+	"github.com/ququzone/ckb-udt-cli/config"
+)
+
+const (
+	retryInitialBackoff = 500 * time.Millisecond
+	retryMaxBackoff     = 10 * time.Second
+
+	fakeChainScheme = "fake://"
+)
+
+// rootContext is cancelled on the first interrupt or termination signal, a second one exits immediately.
+var rootContext, cancelRoot = context.WithCancel(context.Background())
+
+func init() {
+	signals := make(chan os.Signal, 2)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-signals
+		fmt.Fprintln(os.Stderr, "interrupted, cancelling")
+		cancelRoot()
+		<-signals
+		os.Exit(130)
+	}()
+}
+
+// sleepContext waits for d and reports false when ctx is cancelled first.
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// Dial connects to the node and indexer in c. Queries of the returned client time out and are
+// retried with exponential backoff, and every call is cancelled when the process is interrupted.
+// An rpc of fake://FILE loads a FakeChain instead.
+func Dial(c *config.Config) (rpc.Client, error) {
+	if strings.HasPrefix(c.RPC, fakeChainScheme) {
+		return LoadFakeChain(c, strings.TrimPrefix(c.RPC, fakeChainScheme))
+	}
+	timeout, err := time.ParseDuration(c.RPCTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("invalid rpcTimeout %s: %v", c.RPCTimeout, err)
+	}
+	client := &retryClient{
+		ctx:     rootContext,
+		timeout: timeout,
+		retries: c.RPCRetries,
+	}
+	err = client.retry(context.Background(), func(ctx context.Context) error {
+		client.Client, err = rpc.DialWithIndexerContext(ctx, c.RPC, c.CkbIndexer)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return client, nil
+}
+
+type retryClient struct {
+	rpc.Client
+	ctx     context.Context
+	timeout time.Duration
+	retries int
+}
+
+// callContext derives a context bounded by the call timeout that is cancelled with either the process or parent.
+func (c *retryClient) callContext(parent context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithTimeout(c.ctx, c.timeout)
+	if parent.Done() != nil {
+		go func() {
+			select {
+			case <-parent.Done():
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+	}
+	return ctx, cancel
+}
+
+// retryable reports whether err is a transport failure rather than an error returned by the node.
+func retryable(err error) bool {
+	_, ok := err.(interface{ ErrorCode() int })
+	return !ok
+}
+
+func (c *retryClient) retry(parent context.Context, call func(ctx context.Context) error) error {
+	backoff := retryInitialBackoff
+	for attempt := 0; ; attempt++ {
+		ctx, cancel := c.callContext(parent)
+		err := call(ctx)
+		cancel()
+		if err == nil || attempt >= c.retries || !retryable(err) || c.ctx.Err() != nil || parent.Err() != nil {
+			if err != nil && c.ctx.Err() != nil {
+				return c.ctx.Err()
+			}
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "rpc error: %v, retrying in %s\n", err, backoff)
+		if !sleepContext(c.ctx, backoff) {
+			return c.ctx.Err()
+		}
+		backoff *= 2
+		if backoff > retryMaxBackoff {
+			backoff = retryMaxBackoff
+		}
+	}
+}
+
+func (c *retryClient) GetTipBlockNumber(ctx context.Context) (number uint64, err error) {
+	err = c.retry(ctx, func(ctx context.Context) error {
+		number, err = c.Client.GetTipBlockNumber(ctx)
+		return err
+	})
+	return
+}
+
+func (c *retryClient) GetBlockHash(ctx context.Context, number uint64) (hash *types.Hash, err error) {
+	err = c.retry(ctx, func(ctx context.Context) error {
+		hash, err = c.Client.GetBlockHash(ctx, number)
+		return err
+	})
+	return
+}
+
+func (c *retryClient) GetBlock(ctx context.Context, hash types.Hash) (block *types.Block, err error) {
+	err = c.retry(ctx, func(ctx context.Context) error {
+		block, err = c.Client.GetBlock(ctx, hash)
+		return err
+	})
+	return
+}
+
+func (c *retryClient) GetBlockByNumber(ctx context.Context, number uint64) (block *types.Block, err error) {
+	err = c.retry(ctx, func(ctx context.Context) error {
+		block, err = c.Client.GetBlockByNumber(ctx, number)
+		return err
+	})
+	return
+}
+
+func (c *retryClient) GetTipHeader(ctx context.Context) (header *types.Header, err error) {
+	err = c.retry(ctx, func(ctx context.Context) error {
+		header, err = c.Client.GetTipHeader(ctx)
+		return err
+	})
+	return
+}
+
+func (c *retryClient) GetHeader(ctx context.Context, hash types.Hash) (header *types.Header, err error) {
+	err = c.retry(ctx, func(ctx context.Context) error {
+		header, err = c.Client.GetHeader(ctx, hash)
+		return err
+	})
+	return
+}
+
+func (c *retryClient) GetHeaderByNumber(ctx context.Context, number uint64) (header *types.Header, err error) {
+	err = c.retry(ctx, func(ctx context.Context) error {
+		header, err = c.Client.GetHeaderByNumber(ctx, number)
+		return err
+	})
+	return
+}
+
+func (c *retryClient) GetLiveCell(ctx context.Context, outPoint *types.OutPoint, withData bool) (cell *types.CellWithStatus, err error) {
+	err = c.retry(ctx, func(ctx context.Context) error {
+		cell, err = c.Client.GetLiveCell(ctx, outPoint, withData)
+		return err
+	})
+	return
+}
+
+func (c *retryClient) GetTransaction(ctx context.Context, hash types.Hash) (tx *types.TransactionWithStatus, err error) {
+	err = c.retry(ctx, func(ctx context.Context) error {
+		tx, err = c.Client.GetTransaction(ctx, hash)
+		return err
+	})
+	return
+}
+
+func (c *retryClient) DryRunTransaction(ctx context.Context, transaction *types.Transaction) (result *types.DryRunTransactionResult, err error) {
+	err = c.retry(ctx, func(ctx context.Context) error {
+		result, err = c.Client.DryRunTransaction(ctx, transaction)
+		return err
+	})
+	return
+}
+
+func (c *retryClient) GetTip(ctx context.Context) (tip *indexer.TipHeader, err error) {
+	err = c.retry(ctx, func(ctx context.Context) error {
+		tip, err = c.Client.GetTip(ctx)
+		return err
+	})
+	return
+}
+
+func (c *retryClient) GetCellsCapacity(ctx context.Context, searchKey *indexer.SearchKey) (capacity *indexer.Capacity, err error) {
+	err = c.retry(ctx, func(ctx context.Context) error {
+		capacity, err = c.Client.GetCellsCapacity(ctx, searchKey)
+		return err
+	})
+	return
+}
+
+func (c *retryClient) GetCells(ctx context.Context, searchKey *indexer.SearchKey, order indexer.SearchOrder, limit uint64, afterCursor string) (cells *indexer.LiveCells, err error) {
+	err = c.retry(ctx, func(ctx context.Context) error {
+		cells, err = c.Client.GetCells(ctx, searchKey, order, limit, afterCursor)
+		return err
+	})
+	return
+}
+
+func (c *retryClient) GetTransactions(ctx context.Context, searchKey *indexer.SearchKey, order indexer.SearchOrder, limit uint64, afterCursor string) (txs *indexer.Transactions, err error) {
+	err = c.retry(ctx, func(ctx context.Context) error {
+		txs, err = c.Client.GetTransactions(ctx, searchKey, order, limit, afterCursor)
+		return err
+	})
+	return
+}
+
+// SendTransaction sends tx once. When the outcome is ambiguous, e.g. a timeout or a dropped
+// connection, the pool is checked for tx instead of resending it.
+func (c *retryClient) SendTransaction(ctx context.Context, tx *types.Transaction) (*types.Hash, error) {
+	callCtx, cancel := c.callContext(ctx)
+	hash, err := c.Client.SendTransaction(callCtx, tx)
+	cancel()
+	if err == nil || !retryable(err) {
+		return hash, err
+	}
+
+	computed, e := tx.ComputeHash()
+	if e != nil {
+		return nil, err
+	}
+	// the check itself must run even when the process is being interrupted
+	check := &retryClient{Client: c.Client, ctx: context.Background(), timeout: c.timeout, retries: c.retries}
+	sent, e := check.GetTransaction(context.Background(), computed)
+	if e != nil {
+		return nil, fmt.Errorf("%v, and checking whether transaction %s reached the pool failed: %v", err, computed.String(), e)
+	}
+	switch sent.TxStatus.Status {
+	case types.TransactionStatusPending, types.TransactionStatusProposed, types.TransactionStatusCommitted:
+		fmt.Fprintf(os.Stderr, "send transaction error: %v, but transaction %s is %s\n", err, computed.String(), sent.TxStatus.Status)
+		return &computed, nil
+	}
+	return nil, fmt.Errorf("%v, transaction %s is not in the pool, it was not sent", err, computed.String())
+}
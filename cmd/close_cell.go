@@ -0,0 +1,216 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/nervosnetwork/ckb-sdk-go/indexer"
+	"github.com/nervosnetwork/ckb-sdk-go/transaction"
+	"github.com/nervosnetwork/ckb-sdk-go/types"
+	"github.com/nervosnetwork/ckb-sdk-go/utils"
+	"github.com/ququzone/ckb-udt-cli/config"
+	"github.com/spf13/cobra"
+)
+
+var (
+	closeCellConf         *string
+	closeCellKey          *KeyOptions
+	closeCellUUID         *string
+	closeCellStandard     *string
+	closeCellRequireEmpty *bool
+	closeCellSend         *SendOptions
+)
+
+var closeCellCmd = &cobra.Command{
+	Use:   "close-cell",
+	Short: "close anyone can pay cell for sUDT token",
+	Long:  `close anyone can pay cell for sUDT token, moving remaining balance to a secp256k1 sUDT cell and reclaiming capacity.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		c, err := config.Init(*closeCellConf)
+		if err != nil {
+			Fatalf("load config error: %v", err)
+		}
+		if err = c.SelectStandard(*closeCellStandard); err != nil {
+			Fatalf("token standard error: %v", err)
+		}
+
+		client, err := Dial(c)
+		if err != nil {
+			Fatalf("create rpc client error: %v", err)
+		}
+
+		key, err := closeCellKey.Load()
+		if err != nil {
+			Fatalf("import private key error: %v", err)
+		}
+
+		scripts, err := utils.NewSystemScripts(client)
+		if err != nil {
+			Fatalf("load system script error: %v", err)
+		}
+
+		change, err := key.Script(scripts)
+		if err != nil {
+			Fatalf("load system script error: %v", err)
+		}
+		uuid := ParseUUID(*closeCellUUID)
+
+		acpSearchKey := &indexer.SearchKey{
+			Script: &types.Script{
+				CodeHash: types.HexToHash(c.ACP.Script.CodeHash),
+				HashType: types.ScriptHashType(c.ACP.Script.HashType),
+				Args:     change.Args,
+			},
+			ScriptType: "lock",
+		}
+		filter, err := NewOutPointFilter(nil, nil)
+		if err != nil {
+			Fatalf("outpoint filter error: %v", err)
+		}
+		store, err := OpenReservations(client, c, filter)
+		if err != nil {
+			Fatalf("open reservation store error: %v", err)
+		}
+		acpCells, err := CollectUDT(client, c, acpSearchKey, "asc", 1000, "", uuid, nil, filter)
+		if err != nil {
+			Fatalf("collect cell error: %v", err)
+		}
+		if len(acpCells.LiveCells) == 0 {
+			Fatalf("can't find anyone can pay cell for uuid %s", *closeCellUUID)
+		}
+		for _, cell := range acpCells.LiveCells[1:] {
+			if !cell.Output.Lock.Equals(acpCells.LiveCells[0].Output.Lock) {
+				Fatalf("found anyone can pay cells with different lock args, close them separately")
+			}
+		}
+		balance := acpCells.Options["total"].(*big.Int)
+		if *closeCellRequireEmpty && balance.Sign() > 0 {
+			Fatalf("anyone can pay cell still holds %s tokens", balance.String())
+		}
+
+		fee := uint64(1000)
+		required := fee
+		udtCapacity := UDTCellCapacity(change, UDTTypeScript(c, uuid))
+		if balance.Sign() > 0 {
+			required += udtCapacity
+		}
+		var feeCells *utils.LiveCellCollectResult
+		if acpCells.Capacity < required+6100000000 {
+			searchKey := &indexer.SearchKey{
+				Script:     change,
+				ScriptType: "lock",
+			}
+			feeCells, err = SelectCapacity(client, searchKey, required+6100000000-acpCells.Capacity, &oldestFirstSelector{}, filter)
+			if err != nil {
+				Fatalf("collect cell error: %v", err)
+			}
+			if acpCells.Capacity+feeCells.Capacity < required {
+				Fatalf("insufficient capacity: %d < %d", acpCells.Capacity+feeCells.Capacity, required)
+			}
+		}
+		total := acpCells.Capacity
+		if feeCells != nil {
+			total += feeCells.Capacity
+		}
+
+		tx := transaction.NewSecp256k1SingleSigTx(scripts)
+		for _, dep := range c.UDT.Deps {
+			tx.CellDeps = append(tx.CellDeps, &types.CellDep{
+				OutPoint: &types.OutPoint{
+					TxHash: types.HexToHash(dep.TxHash),
+					Index:  dep.Index,
+				},
+				DepType: types.DepType(dep.DepType),
+			})
+		}
+		for _, dep := range c.ACP.Deps {
+			tx.CellDeps = append(tx.CellDeps, &types.CellDep{
+				OutPoint: &types.OutPoint{
+					TxHash: types.HexToHash(dep.TxHash),
+					Index:  dep.Index,
+				},
+				DepType: types.DepType(dep.DepType),
+			})
+		}
+
+		if balance.Sign() > 0 {
+			tx.Outputs = append(tx.Outputs, &types.CellOutput{
+				Capacity: udtCapacity,
+				Lock:     change,
+				Type:     UDTTypeScript(c, uuid),
+			})
+			tx.OutputsData = append(tx.OutputsData, utils.GenerateSudtAmount(balance))
+		}
+		if total-required >= 6100000000 {
+			tx.Outputs = append(tx.Outputs, &types.CellOutput{
+				Capacity: total - required,
+				Lock:     change,
+			})
+			tx.OutputsData = append(tx.OutputsData, []byte{})
+		} else if len(tx.Outputs) > 0 {
+			tx.Outputs[0].Capacity = tx.Outputs[0].Capacity + total - required
+		} else {
+			Fatalf("insufficient capacity for change output: %d", total-required)
+		}
+
+		acpGroup, acpWitnessArgs, err := AddLiveCellInputs(tx, acpCells.LiveCells)
+		if err != nil {
+			Fatalf("add inputs to transaction error: %v", err)
+		}
+		var feeGroup []int
+		var feeWitnessArgs *types.WitnessArgs
+		if feeCells != nil && len(feeCells.LiveCells) > 0 {
+			feeGroup, feeWitnessArgs, err = AddLiveCellInputs(tx, feeCells.LiveCells)
+			if err != nil {
+				Fatalf("add inputs to transaction error: %v", err)
+			}
+		}
+
+		if err = ValidateTransaction(client, c, tx); err != nil {
+			Fatalf("validate transaction error: %v", err)
+		}
+
+		err = transaction.SingleSignTransaction(tx, acpGroup, acpWitnessArgs, key)
+		if err != nil {
+			Fatalf("sign transaction error: %v", err)
+		}
+		if feeGroup != nil {
+			err = transaction.SingleSignTransaction(tx, feeGroup, feeWitnessArgs, key)
+			if err != nil {
+				Fatalf("sign transaction error: %v", err)
+			}
+		}
+
+		closeCellSend.VerifyTransaction(client, c, tx)
+		err = store.Reserve(tx)
+		if err != nil {
+			Fatalf("reserve inputs error: %v", err)
+		}
+		store.Unlock()
+
+		hash, err := client.SendTransaction(context.Background(), tx)
+		if err != nil {
+			_ = store.Release(tx)
+			Fatalf("send transaction error: %s", ExplainScriptError(client, c, tx, err))
+		}
+		if err = store.Confirm(tx, *hash); err != nil {
+			fmt.Printf("record reservation error: %v\n", err)
+		}
+
+		fmt.Printf("close anyone can pay cell transaction hash: %s, moved amount: %s\n", hash.String(), balance.String())
+		closeCellSend.WaitCommitted(client, hash)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(closeCellCmd)
+
+	closeCellConf = closeCellCmd.Flags().StringP("config", "c", "config.yaml", "Config file")
+	closeCellKey = AddKeyFlags(closeCellCmd.Flags(), "Private key")
+	closeCellUUID = closeCellCmd.Flags().StringP("uuid", "u", "", "UDT uuid")
+	closeCellStandard = closeCellCmd.Flags().String("standard", StandardSUDT, "Token standard: sudt or xudt")
+	closeCellRequireEmpty = closeCellCmd.Flags().Bool("require-empty", false, "Fail instead of moving tokens when the cell still holds a balance")
+	closeCellSend = AddSendFlags(closeCellCmd)
+	_ = closeCellCmd.MarkFlagRequired("uuid")
+}
@@ -4,38 +4,47 @@ import (
 	"context"
 	"fmt"
 	"github.com/nervosnetwork/ckb-sdk-go/address"
-	"github.com/nervosnetwork/ckb-sdk-go/crypto/secp256k1"
 	"github.com/nervosnetwork/ckb-sdk-go/indexer"
-	"github.com/nervosnetwork/ckb-sdk-go/rpc"
 	"github.com/nervosnetwork/ckb-sdk-go/transaction"
 	"github.com/nervosnetwork/ckb-sdk-go/types"
 	"github.com/nervosnetwork/ckb-sdk-go/utils"
 	"github.com/ququzone/ckb-udt-cli/config"
 	"github.com/spf13/cobra"
+	"strings"
 )
 
 var (
-	createCellConf *string
-	createCellKey  *string
-	createCellUUID *string
+	createCellConf      *string
+	createCellKey       *KeyOptions
+	createCellUUID      *string
+	createCellStandard  *string
+	createCellMinCKB    *uint8
+	createCellMinUDT    *uint8
+	createCellSend      *SendOptions
+	createCellSelection *string
+	createCellOutPoints *OutPointFlags
+	createCellChain     *bool
 )
 
 var createCellCmd = &cobra.Command{
 	Use:   "create-cell",
 	Short: "create anyone can pay cell for sUDT token",
-	Long:  `create anyone can pay cell for sUDT token.`,
+	Long:  `create anyone can pay cell for sUDT or xUDT token.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		c, err := config.Init(*createCellConf)
 		if err != nil {
 			Fatalf("load config error: %v", err)
 		}
+		if err = c.SelectStandard(*createCellStandard); err != nil {
+			Fatalf("token standard error: %v", err)
+		}
 
-		client, err := rpc.DialWithIndexer(c.RPC, c.CkbIndexer)
+		client, err := Dial(c)
 		if err != nil {
 			Fatalf("create rpc client error: %v", err)
 		}
 
-		key, err := secp256k1.HexToKey(*createCellKey)
+		key, err := createCellKey.Load()
 		if err != nil {
 			Fatalf("import private key error: %v", err)
 		}
@@ -46,17 +55,56 @@ var createCellCmd = &cobra.Command{
 		}
 
 		change, err := key.Script(scripts)
-		capacity := uint64(14200000000)
+		// cell, minimum payment args may need more than the usual 142 CKB
+		var minCKB, minUDT *uint8
+		if cmd.Flags().Changed("min-ckb") {
+			minCKB = createCellMinCKB
+		}
+		if cmd.Flags().Changed("min-udt") {
+			minUDT = createCellMinUDT
+		}
+		lockArgs, err := ACPArgs(change.Args, minCKB, minUDT)
+		if err != nil {
+			Fatalf("build anyone can pay args error: %v", err)
+		}
+		lock := &types.Script{
+			CodeHash: types.HexToHash(c.ACP.Script.CodeHash),
+			HashType: types.ScriptHashType(c.ACP.Script.HashType),
+			Args:     lockArgs,
+		}
+		cellType := &types.Script{
+			CodeHash: types.HexToHash(c.UDT.Script.CodeHash),
+			HashType: types.ScriptHashType(c.UDT.Script.HashType),
+			Args:     ParseUUID(*createCellUUID),
+		}
+		capacity := UDTCellCapacity(lock, cellType)
 		fee := uint64(1000)
 		searchKey := &indexer.SearchKey{
 			Script:     change,
 			ScriptType: "lock",
 		}
-		cellCollector := utils.NewLiveCellCollector(client, searchKey, "asc", 1000, "", utils.NewCapacityLiveCellProcessor(capacity+fee))
-		cells, err := cellCollector.Collect()
+		selector, err := NewCoinSelector(*createCellSelection)
+		if err != nil {
+			Fatalf("coin selection error: %v", err)
+		}
+		filter, err := createCellOutPoints.Filter()
+		if err != nil {
+			Fatalf("outpoint filter error: %v", err)
+		}
+		store, err := OpenReservations(client, c, filter)
+		if err != nil {
+			Fatalf("open reservation store error: %v", err)
+		}
+		if *createCellChain {
+			store.OfferPending(filter)
+		}
+		cells, err := SelectCapacity(client, searchKey, capacity+fee, selector, filter)
 		if err != nil {
 			Fatalf("collect cell error: %v", err)
 		}
+		if missing := filter.Missing(); len(missing) > 0 {
+			Fatalf("pinned outpoints are not spendable: %s", strings.Join(missing, ", "))
+		}
 
 		if cells.Capacity < capacity+fee {
 			Fatalf("insufficient capacity: %d < %d", cells.Capacity, capacity+fee)
@@ -73,24 +121,14 @@ var createCellCmd = &cobra.Command{
 			})
 		}
 
-		// cell
-		lock := &types.Script{
-			CodeHash: types.HexToHash(c.ACP.Script.CodeHash),
-			HashType: types.ScriptHashType(c.ACP.Script.HashType),
-			Args:     change.Args,
-		}
 		tx.Outputs = append(tx.Outputs, &types.CellOutput{
 			Capacity: uint64(capacity),
 			Lock:     lock,
-			Type: &types.Script{
-				CodeHash: types.HexToHash(c.UDT.Script.CodeHash),
-				HashType: types.ScriptHashType(c.UDT.Script.HashType),
-				Args:     types.HexToHash(*createCellUUID).Bytes(),
-			},
+			Type:     cellType,
 		})
 		tx.OutputsData = append(tx.OutputsData, []byte{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0})
 
-		if cells.Capacity-capacity+fee > 6100000000 {
+		if cells.Capacity-capacity-fee >= 6100000000 {
 			tx.Outputs = append(tx.Outputs, &types.CellOutput{
 				Capacity: cells.Capacity - capacity - fee,
 				Lock:     change,
@@ -112,18 +150,34 @@ var createCellCmd = &cobra.Command{
 			Fatalf("add inputs to transaction error: %v", err)
 		}
 
+		if err = ValidateTransaction(client, c, tx); err != nil {
+			Fatalf("validate transaction error: %v", err)
+		}
+
 		err = transaction.SingleSignTransaction(tx, group, witnessArgs, key)
 		if err != nil {
 			Fatalf("sign transaction error: %v", err)
 		}
 
+		createCellSend.VerifyTransaction(client, c, tx)
+		err = store.Reserve(tx)
+		if err != nil {
+			Fatalf("reserve inputs error: %v", err)
+		}
+		store.Unlock()
+
 		hash, err := client.SendTransaction(context.Background(), tx)
 		if err != nil {
-			Fatalf("send transaction error: %v", err)
+			_ = store.Release(tx)
+			Fatalf("send transaction error: %s", ExplainScriptError(client, c, tx, err))
+		}
+		if err = store.Confirm(tx, *hash); err != nil {
+			fmt.Printf("record reservation error: %v\n", err)
 		}
 		addr, _ := address.Generate(address.Testnet, lock)
 
 		fmt.Printf("create anyone can pay cell transaction hash: %s, address: %s\n", hash.String(), addr)
+		createCellSend.WaitCommitted(client, hash)
 	},
 }
 
@@ -131,7 +185,13 @@ func init() {
 	rootCmd.AddCommand(createCellCmd)
 
 	createCellConf = createCellCmd.Flags().StringP("config", "c", "config.yaml", "Config file")
-	createCellKey = createCellCmd.Flags().StringP("key", "k", "", "Private key")
+	createCellKey = AddKeyFlags(createCellCmd.Flags(), "Private key")
 	createCellUUID = createCellCmd.Flags().StringP("uuid", "u", "", "UDT uuid")
-	_ = createCellCmd.MarkFlagRequired("key")
+	createCellStandard = createCellCmd.Flags().String("standard", StandardSUDT, "Token standard: sudt or xudt")
+	createCellMinCKB = createCellCmd.Flags().Uint8("min-ckb", 0, "Minimum CKB payment exponent, payments must be at least 10^x shannons")
+	createCellMinUDT = createCellCmd.Flags().Uint8("min-udt", 0, "Minimum UDT payment exponent, payments must be at least 10^y tokens (requires --min-ckb)")
+	createCellSelection = createCellCmd.Flags().String("coin-selection", OldestFirst, "Coin selection strategy: largest-first, smallest-first, fewest-inputs or oldest-first")
+	createCellOutPoints = AddOutPointFlags(createCellCmd)
+	createCellChain = createCellCmd.Flags().Bool("chain-pending", false, "Allow spending outputs of transactions this tool sent that are not committed yet")
+	createCellSend = AddSendFlags(createCellCmd)
 }
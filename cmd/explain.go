@@ -0,0 +1,300 @@
+package cmd
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"strings"
+
+	"github.com/nervosnetwork/ckb-sdk-go/address"
+	"github.com/nervosnetwork/ckb-sdk-go/indexer"
+	"github.com/nervosnetwork/ckb-sdk-go/rpc"
+	"github.com/nervosnetwork/ckb-sdk-go/types"
+	"github.com/ququzone/ckb-udt-cli/config"
+	"github.com/spf13/cobra"
+)
+
+var (
+	explainConf *string
+)
+
+// LockFlow is what one lock script spent and received in a transaction.
+type LockFlow struct {
+	Lock        *types.Script
+	SpentCKB    uint64
+	ReceivedCKB uint64
+	SpentUDT    map[string]*big.Int
+	ReceivedUDT map[string]*big.Int
+}
+
+// UDTFlow is the total amount of one sUDT in the inputs and outputs of a transaction.
+type UDTFlow struct {
+	UUID   string
+	Input  *big.Int
+	Output *big.Int
+	// Owner is set when an input is locked by the owner lock, which may mint.
+	Owner bool
+}
+
+// ACPTopUp is an anyone can pay cell recreated with more capacity or tokens than it had.
+type ACPTopUp struct {
+	Lock     *types.Script
+	UUID     string
+	Capacity uint64
+	Amount   *big.Int
+}
+
+type TransactionSummary struct {
+	Locks          []*LockFlow
+	UDTs           []*UDTFlow
+	TopUps         []*ACPTopUp
+	InputCapacity  uint64
+	OutputCapacity uint64
+}
+
+// IsUDTScript reports whether script is the configured sUDT type script.
+func IsUDTScript(c *config.Config, script *types.Script) bool {
+	return script != nil &&
+		script.CodeHash == types.HexToHash(c.UDT.Script.CodeHash) &&
+		script.HashType == types.ScriptHashType(c.UDT.Script.HashType)
+}
+
+func scriptArgsString(args []byte) string {
+	return "0x" + hex.EncodeToString(args)
+}
+
+func lockString(lock *types.Script) string {
+	if addr, err := address.Generate(address.Testnet, lock); err == nil {
+		return addr
+	}
+	hash, _ := lock.Hash()
+	return "lock hash " + hash.String()
+}
+
+// Summarize aggregates tx per lock and per sUDT, inputs are the resolved previous outputs of tx.
+func Summarize(c *config.Config, tx *types.Transaction, inputs []*indexer.LiveCell) (*TransactionSummary, error) {
+	summary := &TransactionSummary{}
+	locks := make(map[types.Hash]*LockFlow)
+	flow := func(lock *types.Script) *LockFlow {
+		hash, _ := lock.Hash()
+		f, ok := locks[hash]
+		if !ok {
+			f = &LockFlow{Lock: lock, SpentUDT: make(map[string]*big.Int), ReceivedUDT: make(map[string]*big.Int)}
+			locks[hash] = f
+			summary.Locks = append(summary.Locks, f)
+		}
+		return f
+	}
+	udts := make(map[string]*UDTFlow)
+	udtFlow := func(uuid string) *UDTFlow {
+		f, ok := udts[uuid]
+		if !ok {
+			f = &UDTFlow{UUID: uuid, Input: big.NewInt(0), Output: big.NewInt(0)}
+			udts[uuid] = f
+			summary.UDTs = append(summary.UDTs, f)
+		}
+		return f
+	}
+	add := func(m map[string]*big.Int, uuid string, amount *big.Int) {
+		if _, ok := m[uuid]; !ok {
+			m[uuid] = big.NewInt(0)
+		}
+		m[uuid].Add(m[uuid], amount)
+	}
+
+	lockHashes := make(map[string]bool)
+	for i, cell := range inputs {
+		if cell == nil {
+			continue
+		}
+		summary.InputCapacity += cell.Output.Capacity
+		f := flow(cell.Output.Lock)
+		f.SpentCKB += cell.Output.Capacity
+		hash, _ := cell.Output.Lock.Hash()
+		lockHashes[hash.String()] = true
+		if IsUDTScript(c, cell.Output.Type) {
+			amount, err := ParseUDTAmount(cell.OutputData)
+			if err != nil {
+				return nil, fmt.Errorf("parse sUDT amount of input %d error: %v", i, err)
+			}
+			uuid := scriptArgsString(cell.Output.Type.Args)
+			add(f.SpentUDT, uuid, amount)
+			u := udtFlow(uuid)
+			u.Input.Add(u.Input, amount)
+		}
+	}
+	for i, output := range tx.Outputs {
+		summary.OutputCapacity += output.Capacity
+		f := flow(output.Lock)
+		f.ReceivedCKB += output.Capacity
+		if IsUDTScript(c, output.Type) {
+			amount, err := ParseUDTAmount(tx.OutputsData[i])
+			if err != nil {
+				return nil, fmt.Errorf("parse sUDT amount of output %d error: %v", i, err)
+			}
+			uuid := scriptArgsString(output.Type.Args)
+			add(f.ReceivedUDT, uuid, amount)
+			u := udtFlow(uuid)
+			u.Output.Add(u.Output, amount)
+		}
+	}
+	for _, f := range summary.UDTs {
+		// the owner lock hash leads the args, xUDT args may append flags and extension scripts
+		owner := f.UUID
+		if len(owner) > 2+2*32 {
+			owner = owner[:2+2*32]
+		}
+		f.Owner = lockHashes[owner]
+	}
+
+	// an anyone can pay input recreated with the same lock and type is a top-up when it grew
+	used := make(map[int]bool)
+	for _, cell := range inputs {
+		if cell == nil || cell.Output.Lock.CodeHash != types.HexToHash(c.ACP.Script.CodeHash) {
+			continue
+		}
+		for i, output := range tx.Outputs {
+			if used[i] || !output.Lock.Equals(cell.Output.Lock) || !sameType(output.Type, cell.Output.Type) {
+				continue
+			}
+			used[i] = true
+			if output.Capacity < cell.Output.Capacity {
+				break
+			}
+			topUp := &ACPTopUp{Lock: output.Lock, Capacity: output.Capacity - cell.Output.Capacity, Amount: big.NewInt(0)}
+			if IsUDTScript(c, output.Type) {
+				before, err := ParseUDTAmount(cell.OutputData)
+				if err != nil {
+					break
+				}
+				after, err := ParseUDTAmount(tx.OutputsData[i])
+				if err != nil || after.Cmp(before) < 0 {
+					break
+				}
+				topUp.UUID = scriptArgsString(output.Type.Args)
+				topUp.Amount.Sub(after, before)
+			}
+			if topUp.Capacity > 0 || topUp.Amount.Sign() > 0 {
+				summary.TopUps = append(summary.TopUps, topUp)
+			}
+			break
+		}
+	}
+	return summary, nil
+}
+
+func sameType(a, b *types.Script) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return a.Equals(b)
+}
+
+// loadTransaction reads a transaction from a JSON file or, when no such file exists, queries it by hash.
+func loadTransaction(client rpc.Client, arg string) (*types.Transaction, error) {
+	if _, err := os.Stat(arg); err == nil {
+		data, err := ioutil.ReadFile(arg)
+		if err != nil {
+			return nil, err
+		}
+		return rpc.TransactionFromString(strings.TrimSpace(string(data)))
+	}
+	if len(arg) != 66 || !strings.HasPrefix(arg, "0x") {
+		return nil, fmt.Errorf("%s is neither a transaction file nor a transaction hash", arg)
+	}
+	result, err := client.GetTransaction(context.Background(), types.HexToHash(arg))
+	if err != nil {
+		return nil, err
+	}
+	if result.TxStatus.Status == "" {
+		return nil, fmt.Errorf("transaction %s not found", arg)
+	}
+	return result.Transaction, nil
+}
+
+var explainCmd = &cobra.Command{
+	Use:   "explain TXHASH|FILE",
+	Short: "Explain a transaction",
+	Long:  `Explain a transaction by hash or JSON file, showing what every lock spent and received, the fee, anyone can pay top-ups and sUDT conservation.`,
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		c, err := config.Init(*explainConf)
+		if err != nil {
+			Fatalf("load config error: %v", err)
+		}
+
+		client, err := Dial(c)
+		if err != nil {
+			Fatalf("create rpc client error: %v", err)
+		}
+
+		tx, err := loadTransaction(client, args[0])
+		if err != nil {
+			Fatalf("load transaction error: %v", err)
+		}
+		inputs, err := ResolveInputs(client, tx)
+		if err != nil {
+			Fatalf("resolve inputs error: %v", err)
+		}
+		summary, err := Summarize(c, tx, inputs)
+		if err != nil {
+			Fatalf("explain transaction error: %v", err)
+		}
+
+		hash, _ := tx.ComputeHash()
+		fmt.Printf("transaction %s, %d inputs, %d outputs\n", hash.String(), len(tx.Inputs), len(tx.Outputs))
+		for _, f := range summary.Locks {
+			fmt.Printf("%s\n", lockString(f.Lock))
+			fmt.Printf("  CKB spent: %d received: %d\n", f.SpentCKB, f.ReceivedCKB)
+			for _, u := range summary.UDTs {
+				spent, received := f.SpentUDT[u.UUID], f.ReceivedUDT[u.UUID]
+				if spent == nil && received == nil {
+					continue
+				}
+				if spent == nil {
+					spent = big.NewInt(0)
+				}
+				if received == nil {
+					received = big.NewInt(0)
+				}
+				fmt.Printf("  sUDT %s spent: %s received: %s\n", u.UUID, spent.String(), received.String())
+			}
+		}
+
+		if summary.InputCapacity >= summary.OutputCapacity {
+			fmt.Printf("fee: %d\n", summary.InputCapacity-summary.OutputCapacity)
+		} else {
+			fmt.Printf("fee: invalid, outputs exceed inputs by %d\n", summary.OutputCapacity-summary.InputCapacity)
+		}
+
+		for _, t := range summary.TopUps {
+			if t.UUID != "" {
+				fmt.Printf("anyone can pay top-up %s: CKB +%d sUDT %s +%s\n", lockString(t.Lock), t.Capacity, t.UUID, t.Amount.String())
+			} else {
+				fmt.Printf("anyone can pay top-up %s: CKB +%d\n", lockString(t.Lock), t.Capacity)
+			}
+		}
+
+		for _, u := range summary.UDTs {
+			switch cmp := u.Output.Cmp(u.Input); {
+			case cmp == 0:
+				fmt.Printf("sUDT %s conserved: %s\n", u.UUID, u.Input.String())
+			case cmp < 0:
+				fmt.Printf("sUDT %s burned %s: inputs %s outputs %s\n", u.UUID, big.NewInt(0).Sub(u.Input, u.Output).String(), u.Input.String(), u.Output.String())
+			case u.Owner:
+				fmt.Printf("sUDT %s minted %s by owner: inputs %s outputs %s\n", u.UUID, big.NewInt(0).Sub(u.Output, u.Input).String(), u.Input.String(), u.Output.String())
+			default:
+				fmt.Printf("sUDT %s NOT conserved, outputs exceed inputs without owner: inputs %s outputs %s\n", u.UUID, u.Input.String(), u.Output.String())
+			}
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(explainCmd)
+
+	explainConf = explainCmd.Flags().StringP("config", "c", "config.yaml", "Config file")
+}
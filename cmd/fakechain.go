@@ -0,0 +1,454 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strconv"
+
+	"github.com/nervosnetwork/ckb-sdk-go/indexer"
+	"github.com/nervosnetwork/ckb-sdk-go/rpc"
+	"github.com/nervosnetwork/ckb-sdk-go/types"
+	"github.com/ququzone/ckb-udt-cli/config"
+)
+
+// fakeScriptCycles is the cycles the fake chain charges per script group, real scripts are not run.
+const fakeScriptCycles = 1000000
+
+var errFakeChainUnsupported = errors.New("not supported by fake chain")
+
+var _ rpc.Client = (*FakeChain)(nil)
+
+// FakeChain is an offline stand-in for a node and indexer, selected with rpc: fake://FILE in config.
+// FILE holds {"transactions": [...]} in RPC JSON format, the first two transactions form the
+// genesis block and every later one is committed in its own block. Dry runs resolve inputs and
+// check capacities and sUDT amounts but don't run lock scripts, so signatures aren't verified.
+// Sent transactions are committed and written back to FILE. RPC methods the commands don't use
+// return errFakeChainUnsupported.
+type FakeChain struct {
+	config *config.Config
+	path   string
+	txs    []*types.Transaction
+}
+
+type fakeChainFile struct {
+	Transactions []json.RawMessage `json:"transactions"`
+}
+
+func LoadFakeChain(c *config.Config, path string) (*FakeChain, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var file fakeChainFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+	if len(file.Transactions) < 2 {
+		return nil, errors.New("fake chain needs two genesis transactions")
+	}
+	chain := &FakeChain{config: c, path: path}
+	for i, raw := range file.Transactions {
+		tx, err := rpc.TransactionFromString(string(raw))
+		if err != nil {
+			return nil, fmt.Errorf("parse transaction %d error: %v", i, err)
+		}
+		tx.Hash, err = tx.ComputeHash()
+		if err != nil {
+			return nil, err
+		}
+		chain.txs = append(chain.txs, tx)
+	}
+	return chain, nil
+}
+
+func (f *FakeChain) save() error {
+	var file fakeChainFile
+	for _, tx := range f.txs {
+		s, err := rpc.TransactionString(tx)
+		if err != nil {
+			return err
+		}
+		file.Transactions = append(file.Transactions, json.RawMessage(s))
+	}
+	data, err := json.MarshalIndent(&file, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(f.path, data, 0600)
+}
+
+func (f *FakeChain) blockNumber(i int) uint64 {
+	if i < 2 {
+		return 0
+	}
+	return uint64(i - 1)
+}
+
+func (f *FakeChain) tip() uint64 {
+	return f.blockNumber(len(f.txs) - 1)
+}
+
+// blockHash encodes a block number, fake headers are looked up by it.
+func fakeBlockHash(number uint64) types.Hash {
+	var b [32]byte
+	binary.BigEndian.PutUint64(b[24:], number)
+	return types.BytesToHash(b[:])
+}
+
+func (f *FakeChain) header(number uint64) (*types.Header, error) {
+	if number > f.tip() {
+		return nil, fmt.Errorf("block %d not found", number)
+	}
+	return &types.Header{Hash: fakeBlockHash(number), Number: number, Timestamp: number * 8000}, nil
+}
+
+func (f *FakeChain) find(hash types.Hash) (int, *types.Transaction) {
+	for i, tx := range f.txs {
+		if tx.Hash == hash {
+			return i, tx
+		}
+	}
+	return -1, nil
+}
+
+func (f *FakeChain) spent(outPoint *types.OutPoint) bool {
+	for _, tx := range f.txs {
+		for _, input := range tx.Inputs {
+			if input.PreviousOutput.TxHash == outPoint.TxHash && input.PreviousOutput.Index == outPoint.Index {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (f *FakeChain) GetTipBlockNumber(ctx context.Context) (uint64, error) {
+	return f.tip(), nil
+}
+
+func (f *FakeChain) GetTipHeader(ctx context.Context) (*types.Header, error) {
+	return f.header(f.tip())
+}
+
+func (f *FakeChain) GetHeader(ctx context.Context, hash types.Hash) (*types.Header, error) {
+	return f.header(binary.BigEndian.Uint64(hash.Bytes()[24:]))
+}
+
+func (f *FakeChain) GetHeaderByNumber(ctx context.Context, number uint64) (*types.Header, error) {
+	return f.header(number)
+}
+
+func (f *FakeChain) GetBlockByNumber(ctx context.Context, number uint64) (*types.Block, error) {
+	header, err := f.header(number)
+	if err != nil {
+		return nil, err
+	}
+	block := &types.Block{Header: header}
+	for i, tx := range f.txs {
+		if f.blockNumber(i) == number {
+			block.Transactions = append(block.Transactions, tx)
+		}
+	}
+	return block, nil
+}
+
+func (f *FakeChain) GetBlockHash(ctx context.Context, number uint64) (*types.Hash, error) {
+	header, err := f.header(number)
+	if err != nil {
+		return nil, err
+	}
+	return &header.Hash, nil
+}
+
+func (f *FakeChain) GetBlock(ctx context.Context, hash types.Hash) (*types.Block, error) {
+	return f.GetBlockByNumber(ctx, binary.BigEndian.Uint64(hash.Bytes()[24:]))
+}
+
+func (f *FakeChain) GetTransaction(ctx context.Context, hash types.Hash) (*types.TransactionWithStatus, error) {
+	i, tx := f.find(hash)
+	if tx == nil {
+		return &types.TransactionWithStatus{Transaction: &types.Transaction{}, TxStatus: &types.TxStatus{}}, nil
+	}
+	blockHash := fakeBlockHash(f.blockNumber(i))
+	return &types.TransactionWithStatus{
+		Transaction: tx,
+		TxStatus:    &types.TxStatus{Status: types.TransactionStatusCommitted, BlockHash: &blockHash},
+	}, nil
+}
+
+func (f *FakeChain) GetTip(ctx context.Context) (*indexer.TipHeader, error) {
+	return &indexer.TipHeader{BlockHash: fakeBlockHash(f.tip()), BlockNumber: f.tip()}, nil
+}
+
+func fakeMatch(searchKey *indexer.SearchKey, script *types.Script) bool {
+	return script != nil && script.CodeHash == searchKey.Script.CodeHash &&
+		script.HashType == searchKey.Script.HashType && bytes.HasPrefix(script.Args, searchKey.Script.Args)
+}
+
+func fakeSearchScript(searchKey *indexer.SearchKey, output *types.CellOutput) *types.Script {
+	if searchKey.ScriptType == indexer.ScriptTypeType {
+		return output.Type
+	}
+	return output.Lock
+}
+
+// fakePage applies an indexer order, limit and numeric cursor to n items, returning their indexes and the next cursor.
+func fakePage(n int, order indexer.SearchOrder, limit uint64, afterCursor string) ([]int, string) {
+	start := 0
+	if afterCursor != "" {
+		start, _ = strconv.Atoi(afterCursor)
+	}
+	var result []int
+	for i := start; i < n && uint64(len(result)) < limit; i++ {
+		if order == indexer.SearchOrderDesc {
+			result = append(result, n-1-i)
+		} else {
+			result = append(result, i)
+		}
+	}
+	return result, strconv.Itoa(start + len(result))
+}
+
+func (f *FakeChain) GetCells(ctx context.Context, searchKey *indexer.SearchKey, order indexer.SearchOrder, limit uint64, afterCursor string) (*indexer.LiveCells, error) {
+	var cells []*indexer.LiveCell
+	for i, tx := range f.txs {
+		for j, output := range tx.Outputs {
+			outPoint := &types.OutPoint{TxHash: tx.Hash, Index: uint(j)}
+			if !fakeMatch(searchKey, fakeSearchScript(searchKey, output)) || f.spent(outPoint) {
+				continue
+			}
+			cells = append(cells, &indexer.LiveCell{
+				BlockNumber: f.blockNumber(i),
+				OutPoint:    outPoint,
+				Output:      output,
+				OutputData:  tx.OutputsData[j],
+				TxIndex:     uint(i),
+			})
+		}
+	}
+	page, cursor := fakePage(len(cells), order, limit, afterCursor)
+	result := &indexer.LiveCells{LastCursor: cursor}
+	for _, i := range page {
+		result.Objects = append(result.Objects, cells[i])
+	}
+	return result, nil
+}
+
+func (f *FakeChain) GetCellsCapacity(ctx context.Context, searchKey *indexer.SearchKey) (*indexer.Capacity, error) {
+	cells, err := f.GetCells(ctx, searchKey, indexer.SearchOrderAsc, uint64(len(f.txs))*1000, "")
+	if err != nil {
+		return nil, err
+	}
+	result := &indexer.Capacity{BlockHash: fakeBlockHash(f.tip()), BlockNumber: f.tip()}
+	for _, cell := range cells.Objects {
+		result.Capacity += cell.Output.Capacity
+	}
+	return result, nil
+}
+
+func (f *FakeChain) GetTransactions(ctx context.Context, searchKey *indexer.SearchKey, order indexer.SearchOrder, limit uint64, afterCursor string) (*indexer.Transactions, error) {
+	var items []*indexer.Transaction
+	for i, tx := range f.txs {
+		for j, input := range tx.Inputs {
+			_, previous := f.find(input.PreviousOutput.TxHash)
+			if previous == nil || int(input.PreviousOutput.Index) >= len(previous.Outputs) {
+				continue
+			}
+			if fakeMatch(searchKey, fakeSearchScript(searchKey, previous.Outputs[input.PreviousOutput.Index])) {
+				items = append(items, &indexer.Transaction{BlockNumber: f.blockNumber(i), IoIndex: uint(j), IoType: indexer.IOTypeIn, TxHash: tx.Hash, TxIndex: uint(i)})
+			}
+		}
+		for j, output := range tx.Outputs {
+			if fakeMatch(searchKey, fakeSearchScript(searchKey, output)) {
+				items = append(items, &indexer.Transaction{BlockNumber: f.blockNumber(i), IoIndex: uint(j), IoType: indexer.IOTypeOut, TxHash: tx.Hash, TxIndex: uint(i)})
+			}
+		}
+	}
+	page, cursor := fakePage(len(items), order, limit, afterCursor)
+	result := &indexer.Transactions{LastCursor: cursor}
+	for _, i := range page {
+		result.Objects = append(result.Objects, items[i])
+	}
+	return result, nil
+}
+
+// DryRunTransaction reports errors in the same format as the node so callers parse them alike.
+func (f *FakeChain) DryRunTransaction(ctx context.Context, tx *types.Transaction) (*types.DryRunTransactionResult, error) {
+	inputs := make([]*indexer.LiveCell, len(tx.Inputs))
+	for i, input := range tx.Inputs {
+		outPoint := input.PreviousOutput
+		_, previous := f.find(outPoint.TxHash)
+		raw := make([]byte, 36)
+		copy(raw, outPoint.TxHash.Bytes())
+		binary.LittleEndian.PutUint32(raw[32:], uint32(outPoint.Index))
+		if previous == nil || int(outPoint.Index) >= len(previous.Outputs) {
+			return nil, fmt.Errorf("TransactionFailedToResolve: Resolve failed Unknown(OutPoint(0x%x))", raw)
+		}
+		if f.spent(outPoint) {
+			return nil, fmt.Errorf("TransactionFailedToResolve: Resolve failed Dead(OutPoint(0x%x))", raw)
+		}
+		inputs[i] = &indexer.LiveCell{OutPoint: outPoint, Output: previous.Outputs[outPoint.Index], OutputData: previous.OutputsData[outPoint.Index]}
+	}
+
+	var inputCapacity, outputCapacity uint64
+	for _, cell := range inputs {
+		inputCapacity += cell.Output.Capacity
+	}
+	for i, output := range tx.Outputs {
+		outputCapacity += output.Capacity
+		if output.Capacity < output.OccupiedCapacity(tx.OutputsData[i])*100000000 {
+			return nil, fmt.Errorf("TransactionFailedToVerify: Verification failed Transaction(InsufficientCellCapacity(Outputs[%d]))", i)
+		}
+	}
+	if outputCapacity > inputCapacity {
+		return nil, errors.New("TransactionFailedToVerify: Verification failed Transaction(OutputsSumOverflow)")
+	}
+
+	summary, err := Summarize(f.config, tx, inputs)
+	if err != nil {
+		return nil, err
+	}
+	for _, u := range summary.UDTs {
+		if u.Output.Cmp(u.Input) <= 0 || u.Owner {
+			continue
+		}
+		// the node reports a script group at its first input, or its first output when it has no inputs
+		for i, cell := range inputs {
+			if IsUDTScript(f.config, cell.Output.Type) && scriptArgsString(cell.Output.Type.Args) == u.UUID {
+				return nil, fmt.Errorf("TransactionFailedToVerify: Verification failed Script(TransactionScriptError { source: Inputs[%d].Type, cause: ValidationFailure(-52) })", i)
+			}
+		}
+		for i, output := range tx.Outputs {
+			if IsUDTScript(f.config, output.Type) && scriptArgsString(output.Type.Args) == u.UUID {
+				return nil, fmt.Errorf("TransactionFailedToVerify: Verification failed Script(TransactionScriptError { source: Outputs[%d].Type, cause: ValidationFailure(-52) })", i)
+			}
+		}
+	}
+
+	groups := make(map[types.Hash]bool)
+	for _, cell := range inputs {
+		hash, _ := cell.Output.Lock.Hash()
+		groups[hash] = true
+		if cell.Output.Type != nil {
+			hash, _ = cell.Output.Type.Hash()
+			groups[hash] = true
+		}
+	}
+	for _, output := range tx.Outputs {
+		if output.Type != nil {
+			hash, _ := output.Type.Hash()
+			groups[hash] = true
+		}
+	}
+	return &types.DryRunTransactionResult{Cycles: uint64(len(groups)) * fakeScriptCycles}, nil
+}
+
+func (f *FakeChain) SendTransaction(ctx context.Context, tx *types.Transaction) (*types.Hash, error) {
+	if _, err := f.DryRunTransaction(ctx, tx); err != nil {
+		return nil, err
+	}
+	hash, err := tx.ComputeHash()
+	if err != nil {
+		return nil, err
+	}
+	if _, existing := f.find(hash); existing != nil {
+		return nil, fmt.Errorf("PoolRejectedDuplicatedTransaction: Duplicated(Byte32(%s))", hash.String())
+	}
+	tx.Hash = hash
+	f.txs = append(f.txs, tx)
+	if err := f.save(); err != nil {
+		return nil, err
+	}
+	return &hash, nil
+}
+
+func (f *FakeChain) GetCurrentEpoch(ctx context.Context) (*types.Epoch, error) {
+	return nil, errFakeChainUnsupported
+}
+
+func (f *FakeChain) GetEpochByNumber(ctx context.Context, number uint64) (*types.Epoch, error) {
+	return nil, errFakeChainUnsupported
+}
+
+func (f *FakeChain) GetCellsByLockHash(ctx context.Context, hash types.Hash, from uint64, to uint64) ([]*types.Cell, error) {
+	return nil, errFakeChainUnsupported
+}
+
+func (f *FakeChain) GetLiveCell(ctx context.Context, outPoint *types.OutPoint, withData bool) (*types.CellWithStatus, error) {
+	return nil, errFakeChainUnsupported
+}
+
+func (f *FakeChain) GetCellbaseOutputCapacityDetails(ctx context.Context, hash types.Hash) (*types.BlockReward, error) {
+	return nil, errFakeChainUnsupported
+}
+
+func (f *FakeChain) CalculateDaoMaximumWithdraw(ctx context.Context, point *types.OutPoint, hash types.Hash) (uint64, error) {
+	return 0, errFakeChainUnsupported
+}
+
+func (f *FakeChain) EstimateFeeRate(ctx context.Context, blocks uint64) (*types.EstimateFeeRateResult, error) {
+	return nil, errFakeChainUnsupported
+}
+
+func (f *FakeChain) IndexLockHash(ctx context.Context, lockHash types.Hash, indexFrom uint64) (*types.LockHashIndexState, error) {
+	return nil, errFakeChainUnsupported
+}
+
+func (f *FakeChain) GetLockHashIndexStates(ctx context.Context) ([]*types.LockHashIndexState, error) {
+	return nil, errFakeChainUnsupported
+}
+
+func (f *FakeChain) GetLiveCellsByLockHash(ctx context.Context, lockHash types.Hash, page uint, per uint, reverseOrder bool) ([]*types.LiveCell, error) {
+	return nil, errFakeChainUnsupported
+}
+
+func (f *FakeChain) GetTransactionsByLockHash(ctx context.Context, lockHash types.Hash, page uint, per uint, reverseOrder bool) ([]*types.CellTransaction, error) {
+	return nil, errFakeChainUnsupported
+}
+
+func (f *FakeChain) DeindexLockHash(ctx context.Context, lockHash types.Hash) error {
+	return errFakeChainUnsupported
+}
+
+func (f *FakeChain) LocalNodeInfo(ctx context.Context) (*types.Node, error) {
+	return nil, errFakeChainUnsupported
+}
+
+func (f *FakeChain) GetPeers(ctx context.Context) ([]*types.Node, error) {
+	return nil, errFakeChainUnsupported
+}
+
+func (f *FakeChain) GetBannedAddresses(ctx context.Context) ([]*types.BannedAddress, error) {
+	return nil, errFakeChainUnsupported
+}
+
+func (f *FakeChain) SetBan(ctx context.Context, address string, command string, banTime uint64, absolute bool, reason string) error {
+	return errFakeChainUnsupported
+}
+
+func (f *FakeChain) SendTransactionNoneValidation(ctx context.Context, tx *types.Transaction) (*types.Hash, error) {
+	return nil, errFakeChainUnsupported
+}
+
+func (f *FakeChain) TxPoolInfo(ctx context.Context) (*types.TxPoolInfo, error) {
+	return nil, errFakeChainUnsupported
+}
+
+func (f *FakeChain) GetBlockchainInfo(ctx context.Context) (*types.BlockchainInfo, error) {
+	return nil, errFakeChainUnsupported
+}
+
+func (f *FakeChain) BatchTransactions(ctx context.Context, batch []types.BatchTransactionItem) error {
+	return errFakeChainUnsupported
+}
+
+func (f *FakeChain) BatchLiveCells(ctx context.Context, batch []types.BatchLiveCellItem) error {
+	return errFakeChainUnsupported
+}
+
+func (f *FakeChain) Close() {}
@@ -0,0 +1,215 @@
+package cmd
+
+import (
+	"context"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nervosnetwork/ckb-sdk-go/address"
+	"github.com/nervosnetwork/ckb-sdk-go/crypto/secp256k1"
+	"github.com/nervosnetwork/ckb-sdk-go/indexer"
+	"github.com/nervosnetwork/ckb-sdk-go/transaction"
+	"github.com/nervosnetwork/ckb-sdk-go/types"
+	"github.com/nervosnetwork/ckb-sdk-go/utils"
+	"github.com/ququzone/ckb-udt-cli/config"
+)
+
+const (
+	fixtureIssuerKey = "0000000000000000000000000000000000000000000000000000000000000001"
+	fixtureHolderKey = "0000000000000000000000000000000000000000000000000000000000000002"
+	fixtureUUID      = "0xce6d56bced68d200dc15799378ef8479fefd3243e683253b8b8b672ffde52ac2"
+)
+
+// useFakeChainFixture copies the fixture chain and its config into a temporary directory and
+// changes into it, so sent transactions don't touch testdata.
+func useFakeChainFixture(t *testing.T) func() {
+	dir, err := ioutil.TempDir("", "fakechain")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"fakechain.json", "config.yaml"} {
+		data, err := ioutil.ReadFile(filepath.Join("testdata", name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(dir, name), data, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	return func() {
+		_ = os.Chdir(wd)
+		_ = os.RemoveAll(dir)
+	}
+}
+
+func captureStdout(t *testing.T, f func()) string {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	output := make(chan string)
+	go func() {
+		data, _ := ioutil.ReadAll(r)
+		output <- string(data)
+	}()
+	f()
+	os.Stdout = stdout
+	_ = w.Close()
+	return <-output
+}
+
+// fatalExit is panicked by the exit stub of executeCommand in place of exiting.
+type fatalExit int
+
+// executeCommand runs the root command with args and returns its output. A Fatalf in the command fails
+// the test with the output instead of exiting the test binary.
+func executeCommand(t *testing.T, args ...string) string {
+	defer func(exitHooksBefore []func()) {
+		exit = os.Exit
+		exitHooks = exitHooksBefore
+	}(exitHooks)
+	exit = func(code int) { panic(fatalExit(code)) }
+
+	var err error
+	var exited interface{}
+	output := captureStdout(t, func() {
+		defer func() {
+			if exited = recover(); exited != nil {
+				if _, ok := exited.(fatalExit); !ok {
+					panic(exited)
+				}
+			}
+		}()
+		rootCmd.SetArgs(args)
+		err = rootCmd.Execute()
+	})
+	if exited != nil {
+		t.Fatalf("%s exited with %d:\n%s", args[0], exited, output)
+	}
+	if err != nil {
+		t.Fatalf("%s error: %v\n%s", args[0], err, output)
+	}
+	return output
+}
+
+func TestFakeChainVerify(t *testing.T) {
+	defer useFakeChainFixture(t)()
+
+	c, err := config.Init("config.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, err := Dial(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	scripts, err := utils.NewSystemScripts(client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	holderKey, err := secp256k1.HexToKey(fixtureHolderKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	holder, err := holderKey.Script(scripts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the fixture's secp256k1 code hash is not the well known one, so short addresses don't apply
+	to, err := address.GenerateFullPayloadAddress(address.FullTypeFormat, address.Testnet, holder)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	output := executeCommand(t, "transfer", "-c", "config.yaml", "-k", fixtureIssuerKey, "-u", fixtureUUID, "-a", "10", "-t", to, "--verify")
+	// one secp256k1 lock group and one sUDT type group
+	if !strings.Contains(output, "dry run passed, cycles: 2000000\n") {
+		t.Fatalf("verify didn't report cycles:\n%s", output)
+	}
+	if !strings.Contains(output, "transfer transaction hash: 0x") {
+		t.Fatalf("transfer wasn't sent:\n%s", output)
+	}
+
+	// reload the chain the transfer was committed to
+	client, err = Dial(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cells, err := client.GetCells(context.Background(), &indexer.SearchKey{Script: holder, ScriptType: indexer.ScriptTypeLock}, indexer.SearchOrderAsc, 10, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cells.Objects) != 1 || UDTValue(cells.Objects[0]).Int64() != 10 {
+		t.Fatalf("expect one holder cell of 10 tokens, got %d cells", len(cells.Objects))
+	}
+	cell := cells.Objects[0]
+
+	tests := []struct {
+		name   string
+		output *types.CellOutput
+		amount int64
+		want   string
+	}{
+		{
+			name:   "outputs exceed inputs",
+			output: cell.Output,
+			amount: 11,
+			want:   "sUDT type script error -52 at input 0 type script (" + OutPointString(cell.OutPoint) + ")",
+		},
+		{
+			name: "mint without inputs",
+			output: &types.CellOutput{
+				Capacity: cell.Output.Capacity,
+				Lock:     holder,
+				Type:     UDTTypeScript(c, types.HexToHash("0x01").Bytes()),
+			},
+			amount: 1,
+			want:   "sUDT type script error -52 at output 0 type script",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tx := transaction.NewSecp256k1SingleSigTx(scripts)
+			for _, dep := range c.UDT.Deps {
+				tx.CellDeps = append(tx.CellDeps, &types.CellDep{
+					OutPoint: &types.OutPoint{TxHash: types.HexToHash(dep.TxHash), Index: dep.Index},
+					DepType:  types.DepType(dep.DepType),
+				})
+			}
+			data, err := UpdateSudtAmount(cell.OutputData, big.NewInt(tt.amount))
+			if err != nil {
+				t.Fatal(err)
+			}
+			tx.Outputs = append(tx.Outputs, tt.output)
+			tx.OutputsData = append(tx.OutputsData, data)
+			group, witnessArgs, err := AddLiveCellInputs(tx, []*indexer.LiveCell{cell})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err = transaction.SingleSignTransaction(tx, group, witnessArgs, holderKey); err != nil {
+				t.Fatal(err)
+			}
+
+			_, err = client.DryRunTransaction(context.Background(), tx)
+			if err == nil {
+				t.Fatal("expect dry run to fail")
+			}
+			if got := ExplainScriptError(client, c, tx, err); !strings.HasPrefix(got, tt.want) {
+				t.Fatalf("got %q, want prefix %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,163 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/nervosnetwork/ckb-sdk-go/address"
+	"github.com/nervosnetwork/ckb-sdk-go/indexer"
+	"github.com/nervosnetwork/ckb-sdk-go/rpc"
+	"github.com/nervosnetwork/ckb-sdk-go/types"
+	"github.com/ququzone/ckb-udt-cli/config"
+	"github.com/spf13/cobra"
+)
+
+var (
+	historyConf   *string
+	historyUUID   *string
+	historyAddr   *string
+	historyLimit  *uint64
+	historyCursor *string
+	historyOrder  *string
+)
+
+var historyCmd = &cobra.Command{
+	Use:   "history",
+	Short: "Query sUDT transaction history",
+	Long:  `Query sUDT transaction history of an address, showing the net token change of every transaction.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		c, err := config.Init(*historyConf)
+		if err != nil {
+			Fatalf("load config error: %v", err)
+		}
+
+		client, err := Dial(c)
+		if err != nil {
+			Fatalf("create rpc client error: %v", err)
+		}
+
+		addr, err := address.Parse(*historyAddr)
+		if err != nil {
+			Fatalf("parse address error: %v", err)
+		}
+		udtScript := UDTTypeScript(c, types.HexToHash(*historyUUID).Bytes())
+
+		searchKey := &indexer.SearchKey{
+			Script:     addr.Script,
+			ScriptType: indexer.ScriptTypeLock,
+		}
+		order := indexer.SearchOrder(*historyOrder)
+		txs, err := client.GetTransactions(context.Background(), searchKey, order, *historyLimit, *historyCursor)
+		if err != nil {
+			Fatalf("query transactions error: %v", err)
+		}
+
+		// the indexer returns a row per matching cell, so a transaction may continue past the page. Read its
+		// remaining rows here so the next cursor starts at a new transaction and none is printed twice.
+		cursor, more := txs.LastCursor, len(txs.Objects) == int(*historyLimit) && txs.LastCursor != ""
+		if more {
+			last := txs.Objects[len(txs.Objects)-1].TxHash
+			for {
+				next, err := client.GetTransactions(context.Background(), searchKey, order, 1, cursor)
+				if err != nil {
+					Fatalf("query transactions error: %v", err)
+				}
+				if len(next.Objects) == 0 {
+					more = false
+					break
+				}
+				if next.Objects[0].TxHash != last {
+					break
+				}
+				cursor = next.LastCursor
+			}
+		}
+
+		seen := make(map[types.Hash]bool)
+		for _, item := range txs.Objects {
+			if seen[item.TxHash] {
+				continue
+			}
+			seen[item.TxHash] = true
+
+			delta, involved, err := udtDelta(client, item.TxHash, addr.Script, udtScript)
+			if err != nil {
+				Fatalf("resolve transaction %s error: %v", item.TxHash.String(), err)
+			}
+			if !involved {
+				continue
+			}
+			header, err := client.GetHeaderByNumber(context.Background(), item.BlockNumber)
+			if err != nil {
+				Fatalf("query header error: %v", err)
+			}
+			timestamp := time.Unix(0, int64(header.Timestamp)*int64(time.Millisecond)).UTC()
+			sign := ""
+			if delta.Sign() > 0 {
+				sign = "+"
+			}
+			fmt.Printf("%s block: %d time: %s amount: %s%s\n", item.TxHash.String(), item.BlockNumber, timestamp.Format(time.RFC3339), sign, delta.String())
+		}
+
+		if more {
+			fmt.Printf("next cursor: %s\n", cursor)
+		}
+	},
+}
+
+// udtDelta returns the net sUDT change of lock in a transaction, and whether lock held any matching cell in it.
+func udtDelta(client rpc.Client, hash types.Hash, lock *types.Script, udtScript *types.Script) (*big.Int, bool, error) {
+	tx, err := client.GetTransaction(context.Background(), hash)
+	if err != nil {
+		return nil, false, err
+	}
+	delta := big.NewInt(0)
+	involved := false
+	for i, output := range tx.Transaction.Outputs {
+		if !lock.Equals(output.Lock) || !udtScript.Equals(output.Type) {
+			continue
+		}
+		amount, err := ParseUDTAmount(tx.Transaction.OutputsData[i])
+		if err != nil {
+			return nil, false, err
+		}
+		delta.Add(delta, amount)
+		involved = true
+	}
+	for _, input := range tx.Transaction.Inputs {
+		// cellbase input
+		if input.PreviousOutput.TxHash == (types.Hash{}) {
+			continue
+		}
+		previous, err := client.GetTransaction(context.Background(), input.PreviousOutput.TxHash)
+		if err != nil {
+			return nil, false, err
+		}
+		output := previous.Transaction.Outputs[input.PreviousOutput.Index]
+		if !lock.Equals(output.Lock) || !udtScript.Equals(output.Type) {
+			continue
+		}
+		amount, err := ParseUDTAmount(previous.Transaction.OutputsData[input.PreviousOutput.Index])
+		if err != nil {
+			return nil, false, err
+		}
+		delta.Sub(delta, amount)
+		involved = true
+	}
+	return delta, involved, nil
+}
+
+func init() {
+	rootCmd.AddCommand(historyCmd)
+
+	historyConf = historyCmd.Flags().StringP("config", "c", "config.yaml", "Config file")
+	historyUUID = historyCmd.Flags().StringP("uuid", "u", "", "UDT uuid")
+	historyAddr = historyCmd.Flags().StringP("address", "a", "", "Address")
+	historyLimit = historyCmd.Flags().Uint64P("limit", "l", 20, "Number of indexer records per page")
+	historyCursor = historyCmd.Flags().String("cursor", "", "Cursor returned by the previous page")
+	historyOrder = historyCmd.Flags().String("order", "desc", "Search order, asc or desc")
+	_ = historyCmd.MarkFlagRequired("uuid")
+	_ = historyCmd.MarkFlagRequired("address")
+}
@@ -0,0 +1,226 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"sort"
+	"strconv"
+
+	"github.com/nervosnetwork/ckb-sdk-go/address"
+	"github.com/nervosnetwork/ckb-sdk-go/indexer"
+	"github.com/nervosnetwork/ckb-sdk-go/rpc"
+	"github.com/nervosnetwork/ckb-sdk-go/types"
+	"github.com/ququzone/ckb-udt-cli/config"
+	"github.com/spf13/cobra"
+)
+
+const holdersPageSize = 1000
+
+var (
+	holdersConf   *string
+	holdersUUID   *string
+	holdersOutput *string
+	holdersFormat *string
+)
+
+// Holder is the sUDT held by one lock script.
+type Holder struct {
+	Lock     *types.Script
+	LockHash types.Hash
+	Address  string
+	Amount   *big.Int
+	Cells    int
+}
+
+// HolderSnapshot lists every holder of a sUDT at an indexer tip, largest amount first.
+type HolderSnapshot struct {
+	UUID    types.Hash
+	Tip     *indexer.TipHeader
+	Total   *big.Int
+	Holders []*Holder
+}
+
+// CollectHolders pages through the live cells of the sUDT type script and aggregates their amounts per lock.
+func CollectHolders(client rpc.Client, c *config.Config, uuid types.Hash) (*HolderSnapshot, error) {
+	tip, err := client.GetTip(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	searchKey := &indexer.SearchKey{
+		Script:     UDTTypeScript(c, uuid.Bytes()),
+		ScriptType: indexer.ScriptTypeType,
+	}
+	snapshot := &HolderSnapshot{UUID: uuid, Tip: tip, Total: big.NewInt(0)}
+	holders := make(map[types.Hash]*Holder)
+	cursor := ""
+	for {
+		cells, err := client.GetCells(context.Background(), searchKey, indexer.SearchOrderAsc, holdersPageSize, cursor)
+		if err != nil {
+			return nil, err
+		}
+		for _, cell := range cells.Objects {
+			// the indexer matches args by prefix
+			if !bytes.Equal(cell.Output.Type.Args, uuid.Bytes()) {
+				continue
+			}
+			amount, err := ParseUDTAmount(cell.OutputData)
+			if err != nil {
+				return nil, fmt.Errorf("parse amount of cell %s:%d error: %v", cell.OutPoint.TxHash.String(), cell.OutPoint.Index, err)
+			}
+			hash, err := cell.Output.Lock.Hash()
+			if err != nil {
+				return nil, err
+			}
+			holder, ok := holders[hash]
+			if !ok {
+				holder = &Holder{Lock: cell.Output.Lock, LockHash: hash, Amount: big.NewInt(0)}
+				if holder.Address, err = address.Generate(address.Testnet, cell.Output.Lock); err != nil {
+					return nil, err
+				}
+				holders[hash] = holder
+				snapshot.Holders = append(snapshot.Holders, holder)
+			}
+			holder.Amount.Add(holder.Amount, amount)
+			holder.Cells++
+			snapshot.Total.Add(snapshot.Total, amount)
+		}
+		if len(cells.Objects) < holdersPageSize || cells.LastCursor == "" {
+			break
+		}
+		cursor = cells.LastCursor
+	}
+
+	end, err := client.GetTip(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	if end.BlockNumber != tip.BlockNumber || end.BlockHash != tip.BlockHash {
+		fmt.Fprintf(os.Stderr, "warning: indexer tip moved from %d to %d while paging, the snapshot may mix both states\n", tip.BlockNumber, end.BlockNumber)
+	}
+
+	sort.SliceStable(snapshot.Holders, func(i, j int) bool {
+		return snapshot.Holders[i].Amount.Cmp(snapshot.Holders[j].Amount) > 0
+	})
+	return snapshot, nil
+}
+
+func writeHoldersCSV(w io.Writer, snapshot *HolderSnapshot) error {
+	writer := csv.NewWriter(w)
+	records := [][]string{{"address", "lock_hash", "amount", "cells", "uuid", "tip_block_number", "tip_block_hash"}}
+	for _, holder := range snapshot.Holders {
+		records = append(records, []string{
+			holder.Address,
+			holder.LockHash.String(),
+			holder.Amount.String(),
+			strconv.Itoa(holder.Cells),
+			snapshot.UUID.String(),
+			strconv.FormatUint(snapshot.Tip.BlockNumber, 10),
+			snapshot.Tip.BlockHash.String(),
+		})
+	}
+	return writer.WriteAll(records)
+}
+
+func writeHoldersJSON(w io.Writer, snapshot *HolderSnapshot) error {
+	type holderJSON struct {
+		Address  string `json:"address"`
+		LockHash string `json:"lockHash"`
+		Amount   string `json:"amount"`
+		Cells    int    `json:"cells"`
+	}
+	result := struct {
+		UUID           string       `json:"uuid"`
+		TipBlockNumber uint64       `json:"tipBlockNumber"`
+		TipBlockHash   string       `json:"tipBlockHash"`
+		TotalSupply    string       `json:"totalSupply"`
+		HolderCount    int          `json:"holderCount"`
+		Holders        []holderJSON `json:"holders"`
+	}{
+		UUID:           snapshot.UUID.String(),
+		TipBlockNumber: snapshot.Tip.BlockNumber,
+		TipBlockHash:   snapshot.Tip.BlockHash.String(),
+		TotalSupply:    snapshot.Total.String(),
+		HolderCount:    len(snapshot.Holders),
+		Holders:        make([]holderJSON, 0, len(snapshot.Holders)),
+	}
+	for _, holder := range snapshot.Holders {
+		result.Holders = append(result.Holders, holderJSON{
+			Address:  holder.Address,
+			LockHash: holder.LockHash.String(),
+			Amount:   holder.Amount.String(),
+			Cells:    holder.Cells,
+		})
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(&result)
+}
+
+var holdersCmd = &cobra.Command{
+	Use:   "holders",
+	Short: "List sUDT holders",
+	Long:  `List every holder of a sUDT with total supply and holder count, optionally writing a CSV or JSON snapshot tagged with the indexer tip.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		c, err := config.Init(*holdersConf)
+		if err != nil {
+			Fatalf("load config error: %v", err)
+		}
+
+		var write func(io.Writer, *HolderSnapshot) error
+		switch *holdersFormat {
+		case "csv":
+			write = writeHoldersCSV
+		case "json":
+			write = writeHoldersJSON
+		default:
+			Fatalf("unknown format %s, expect csv or json", *holdersFormat)
+		}
+
+		client, err := Dial(c)
+		if err != nil {
+			Fatalf("create rpc client error: %v", err)
+		}
+
+		snapshot, err := CollectHolders(client, c, types.HexToHash(*holdersUUID))
+		if err != nil {
+			Fatalf("collect holders error: %v", err)
+		}
+
+		if *holdersOutput == "" {
+			for _, holder := range snapshot.Holders {
+				fmt.Printf("%s amount: %s cells: %d\n", holder.Address, holder.Amount.String(), holder.Cells)
+			}
+		} else {
+			file, err := os.Create(*holdersOutput)
+			if err != nil {
+				Fatalf("create snapshot error: %v", err)
+			}
+			err = write(file, snapshot)
+			if closeErr := file.Close(); err == nil {
+				err = closeErr
+			}
+			if err != nil {
+				Fatalf("write snapshot error: %v", err)
+			}
+			fmt.Printf("snapshot: %s\n", *holdersOutput)
+		}
+		fmt.Printf("tip block: %d %s\n", snapshot.Tip.BlockNumber, snapshot.Tip.BlockHash.String())
+		fmt.Printf("total supply: %s, holders: %d\n", snapshot.Total.String(), len(snapshot.Holders))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(holdersCmd)
+
+	holdersConf = holdersCmd.Flags().StringP("config", "c", "config.yaml", "Config file")
+	holdersUUID = holdersCmd.Flags().StringP("uuid", "u", "", "UDT uuid")
+	holdersOutput = holdersCmd.Flags().StringP("output", "o", "", "Write the snapshot to this file instead of listing holders")
+	holdersFormat = holdersCmd.Flags().String("format", "csv", "Snapshot format: csv or json")
+	_ = holdersCmd.MarkFlagRequired("uuid")
+}
@@ -0,0 +1,340 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/nervosnetwork/ckb-sdk-go/indexer"
+	"github.com/nervosnetwork/ckb-sdk-go/rpc"
+	"github.com/nervosnetwork/ckb-sdk-go/transaction"
+	"github.com/nervosnetwork/ckb-sdk-go/types"
+	"github.com/nervosnetwork/ckb-sdk-go/utils"
+	"github.com/ququzone/ckb-udt-cli/config"
+	"github.com/spf13/cobra"
+)
+
+const infoPageSize = 100
+
+var (
+	infoConf       *string
+	infoCreateKey  *KeyOptions
+	infoUUID       *string
+	infoDecimals   *uint8
+	infoName       *string
+	infoSymbol     *string
+	infoSelection  *string
+	infoCreateSend *SendOptions
+)
+
+// TokenInfo is the content of a sUDT info cell: decimals, then name and symbol each prefixed by its byte length.
+type TokenInfo struct {
+	Decimals uint8
+	Name     string
+	Symbol   string
+}
+
+func (i *TokenInfo) Bytes() ([]byte, error) {
+	if len(i.Name) > 255 || len(i.Symbol) > 255 {
+		return nil, errors.New("name and symbol must be at most 255 bytes")
+	}
+	data := []byte{i.Decimals, byte(len(i.Name))}
+	data = append(data, i.Name...)
+	data = append(data, byte(len(i.Symbol)))
+	return append(data, i.Symbol...), nil
+}
+
+// ParseTokenInfo decodes sUDT info cell data, ignoring trailing fields.
+func ParseTokenInfo(data []byte) (*TokenInfo, error) {
+	if len(data) < 2 {
+		return nil, errors.New("info data too short")
+	}
+	info := &TokenInfo{Decimals: data[0]}
+	nameEnd := 2 + int(data[1])
+	if len(data) < nameEnd+1 {
+		return nil, errors.New("info data too short for name")
+	}
+	info.Name = string(data[2:nameEnd])
+	symbolEnd := nameEnd + 1 + int(data[nameEnd])
+	if len(data) < symbolEnd {
+		return nil, errors.New("info data too short for symbol")
+	}
+	info.Symbol = string(data[nameEnd+1 : symbolEnd])
+	if !utf8.ValidString(info.Name) || !utf8.ValidString(info.Symbol) {
+		return nil, errors.New("name or symbol is not valid utf-8")
+	}
+	return info, nil
+}
+
+// FormatAmount renders amount with decimals digits after the decimal point, trimming trailing zeros.
+func FormatAmount(amount *big.Int, decimals uint8) string {
+	if decimals == 0 {
+		return amount.String()
+	}
+	digits := new(big.Int).Abs(amount).String()
+	if len(digits) <= int(decimals) {
+		digits = strings.Repeat("0", int(decimals)-len(digits)+1) + digits
+	}
+	point := len(digits) - int(decimals)
+	result := digits[:point]
+	if fraction := strings.TrimRight(digits[point:], "0"); fraction != "" {
+		result += "." + fraction
+	}
+	if amount.Sign() < 0 {
+		result = "-" + result
+	}
+	return result
+}
+
+// InfoTypeScript returns the info cell type script of uuid, bound to the token by the hash of its sUDT type script.
+func InfoTypeScript(c *config.Config, uuid []byte) (*types.Script, error) {
+	if c.Info.Script.CodeHash == "" {
+		return nil, errors.New("info script is not configured")
+	}
+	udtHash, err := UDTTypeScript(c, uuid).Hash()
+	if err != nil {
+		return nil, err
+	}
+	return &types.Script{
+		CodeHash: types.HexToHash(c.Info.Script.CodeHash),
+		HashType: types.ScriptHashType(c.Info.Script.HashType),
+		Args:     udtHash.Bytes(),
+	}, nil
+}
+
+// FindTokenInfo returns the info cell of uuid held by the issuer lock, or nil when there is none.
+func FindTokenInfo(client rpc.Client, c *config.Config, uuid types.Hash) (*TokenInfo, *indexer.LiveCell, error) {
+	infoType, err := InfoTypeScript(c, uuid.Bytes())
+	if err != nil {
+		return nil, nil, err
+	}
+	searchKey := &indexer.SearchKey{
+		Script:     infoType,
+		ScriptType: indexer.ScriptTypeType,
+	}
+	cursor := ""
+	for {
+		cells, err := client.GetCells(context.Background(), searchKey, indexer.SearchOrderDesc, infoPageSize, cursor)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, cell := range cells.Objects {
+			// anyone may create a cell with the info type, only the issuer's is trusted
+			if hash, err := cell.Output.Lock.Hash(); err != nil || hash != uuid {
+				continue
+			}
+			info, err := ParseTokenInfo(cell.OutputData)
+			if err != nil {
+				return nil, nil, fmt.Errorf("parse info cell %s:%d error: %v", cell.OutPoint.TxHash.String(), cell.OutPoint.Index, err)
+			}
+			return info, cell, nil
+		}
+		if len(cells.Objects) < infoPageSize || cells.LastCursor == "" {
+			return nil, nil, nil
+		}
+		cursor = cells.LastCursor
+	}
+}
+
+var infoCmd = &cobra.Command{
+	Use:   "info",
+	Short: "sUDT token info",
+	Long:  `Create or show the info cell holding the decimals, name and symbol of a sUDT.`,
+}
+
+var infoCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Create sUDT info cell",
+	Long:  `Create the info cell of a sUDT under the issuer's lock.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		c, err := config.Init(*infoConf)
+		if err != nil {
+			Fatalf("load config error: %v", err)
+		}
+
+		client, err := Dial(c)
+		if err != nil {
+			Fatalf("create rpc client error: %v", err)
+		}
+
+		key, err := infoCreateKey.Load()
+		if err != nil {
+			Fatalf("import private key error: %v", err)
+		}
+
+		scripts, err := utils.NewSystemScripts(client)
+		if err != nil {
+			Fatalf("load system script error: %v", err)
+		}
+
+		change, err := key.Script(scripts)
+		if err != nil {
+			Fatalf("load system script error: %v", err)
+		}
+		uuid := types.HexToHash(*infoUUID)
+		if lockHash, _ := change.Hash(); lockHash != uuid {
+			Fatalf("key is not the issuer of %s, its uuid is %s", uuid.String(), lockHash.String())
+		}
+
+		existing, cell, err := FindTokenInfo(client, c, uuid)
+		if err != nil {
+			Fatalf("query info cell error: %v", err)
+		}
+		if existing != nil {
+			Fatalf("info cell %s:%d already exists", cell.OutPoint.TxHash.String(), cell.OutPoint.Index)
+		}
+
+		infoType, err := InfoTypeScript(c, uuid.Bytes())
+		if err != nil {
+			Fatalf("info script error: %v", err)
+		}
+		data, err := (&TokenInfo{Decimals: *infoDecimals, Name: *infoName, Symbol: *infoSymbol}).Bytes()
+		if err != nil {
+			Fatalf("encode info error: %v", err)
+		}
+		output := &types.CellOutput{
+			Lock: change,
+			Type: infoType,
+		}
+		capacity := output.OccupiedCapacity(data) * 100000000
+		output.Capacity = capacity
+
+		fee := uint64(1000)
+		searchKey := &indexer.SearchKey{
+			Script:     change,
+			ScriptType: indexer.ScriptTypeLock,
+		}
+		selector, err := NewCoinSelector(*infoSelection)
+		if err != nil {
+			Fatalf("coin selection error: %v", err)
+		}
+		filter, err := NewOutPointFilter(nil, nil)
+		if err != nil {
+			Fatalf("outpoint filter error: %v", err)
+		}
+		store, err := OpenReservations(client, c, filter)
+		if err != nil {
+			Fatalf("open reservation store error: %v", err)
+		}
+		cells, err := SelectCapacity(client, searchKey, capacity+fee, selector, filter)
+		if err != nil {
+			Fatalf("collect cell error: %v", err)
+		}
+		if cells.Capacity < capacity+fee {
+			Fatalf("insufficient capacity: %d < %d", cells.Capacity, capacity+fee)
+		}
+
+		tx := transaction.NewSecp256k1SingleSigTx(scripts)
+		for _, dep := range c.Info.Deps {
+			tx.CellDeps = append(tx.CellDeps, &types.CellDep{
+				OutPoint: &types.OutPoint{
+					TxHash: types.HexToHash(dep.TxHash),
+					Index:  dep.Index,
+				},
+				DepType: types.DepType(dep.DepType),
+			})
+		}
+		tx.Outputs = append(tx.Outputs, output)
+		tx.OutputsData = append(tx.OutputsData, data)
+		if cells.Capacity-capacity-fee >= 6100000000 {
+			tx.Outputs = append(tx.Outputs, &types.CellOutput{
+				Capacity: cells.Capacity - capacity - fee,
+				Lock:     change,
+			})
+			tx.OutputsData = append(tx.OutputsData, []byte{})
+		} else {
+			tx.Outputs[0].Capacity = tx.Outputs[0].Capacity + cells.Capacity - capacity - fee
+		}
+		var inputs []*types.CellInput
+		for _, cell := range cells.LiveCells {
+			inputs = append(inputs, &types.CellInput{
+				Since:          0,
+				PreviousOutput: cell.OutPoint,
+			})
+		}
+		group, witnessArgs, err := transaction.AddInputsForTransaction(tx, inputs)
+		if err != nil {
+			Fatalf("add inputs to transaction error: %v", err)
+		}
+
+		if err = ValidateTransaction(client, c, tx); err != nil {
+			Fatalf("validate transaction error: %v", err)
+		}
+
+		err = transaction.SingleSignTransaction(tx, group, witnessArgs, key)
+		if err != nil {
+			Fatalf("sign transaction error: %v", err)
+		}
+
+		infoCreateSend.VerifyTransaction(client, c, tx)
+		err = store.Reserve(tx)
+		if err != nil {
+			Fatalf("reserve inputs error: %v", err)
+		}
+		store.Unlock()
+
+		hash, err := client.SendTransaction(context.Background(), tx)
+		if err != nil {
+			_ = store.Release(tx)
+			Fatalf("send transaction error: %s", ExplainScriptError(client, c, tx, err))
+		}
+		if err = store.Confirm(tx, *hash); err != nil {
+			fmt.Printf("record reservation error: %v\n", err)
+		}
+
+		fmt.Printf("create info cell transaction hash: %s\n", hash.String())
+		infoCreateSend.WaitCommitted(client, hash)
+	},
+}
+
+var infoShowCmd = &cobra.Command{
+	Use:   "show UUID",
+	Short: "Show sUDT info",
+	Long:  `Find the info cell of a sUDT held by its issuer and show the decimals, name and symbol.`,
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		c, err := config.Init(*infoConf)
+		if err != nil {
+			Fatalf("load config error: %v", err)
+		}
+
+		client, err := Dial(c)
+		if err != nil {
+			Fatalf("create rpc client error: %v", err)
+		}
+
+		info, cell, err := FindTokenInfo(client, c, types.HexToHash(args[0]))
+		if err != nil {
+			Fatalf("query info cell error: %v", err)
+		}
+		if info == nil {
+			Fatalf("no info cell found for %s", args[0])
+		}
+
+		fmt.Printf("info cell: %s:%d\n", cell.OutPoint.TxHash.String(), cell.OutPoint.Index)
+		fmt.Printf("name: %s\n", info.Name)
+		fmt.Printf("symbol: %s\n", info.Symbol)
+		fmt.Printf("decimals: %d\n", info.Decimals)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(infoCmd)
+	infoCmd.AddCommand(infoCreateCmd)
+	infoCmd.AddCommand(infoShowCmd)
+
+	infoConf = infoCmd.PersistentFlags().StringP("config", "c", "config.yaml", "Config file")
+	infoCreateKey = AddKeyFlags(infoCreateCmd.Flags(), "Issuer private key")
+	infoUUID = infoCreateCmd.Flags().StringP("uuid", "u", "", "UDT uuid")
+	infoDecimals = infoCreateCmd.Flags().Uint8("decimals", 8, "Number of decimals")
+	infoName = infoCreateCmd.Flags().String("name", "", "Token name")
+	infoSymbol = infoCreateCmd.Flags().String("symbol", "", "Token symbol")
+	infoSelection = infoCreateCmd.Flags().String("coin-selection", OldestFirst, "Coin selection strategy: largest-first, smallest-first, fewest-inputs or oldest-first")
+	infoCreateSend = AddSendFlags(infoCreateCmd)
+	_ = infoCreateCmd.MarkFlagRequired("uuid")
+	_ = infoCreateCmd.MarkFlagRequired("name")
+	_ = infoCreateCmd.MarkFlagRequired("symbol")
+}
@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"context"
+	"testing"
+
+	"github.com/nervosnetwork/ckb-sdk-go/indexer"
+	"github.com/nervosnetwork/ckb-sdk-go/rpc"
+	"github.com/nervosnetwork/ckb-sdk-go/types"
+	"github.com/ququzone/ckb-udt-cli/config"
+)
+
+// stubCells serves cells from GetCells with the fake chain's paging.
+type stubCells struct {
+	rpc.Client
+	cells []*indexer.LiveCell
+	pages int
+}
+
+func (s *stubCells) GetCells(ctx context.Context, searchKey *indexer.SearchKey, order indexer.SearchOrder, limit uint64, afterCursor string) (*indexer.LiveCells, error) {
+	s.pages++
+	page, cursor := fakePage(len(s.cells), order, limit, afterCursor)
+	result := &indexer.LiveCells{LastCursor: cursor}
+	for _, i := range page {
+		result.Objects = append(result.Objects, s.cells[i])
+	}
+	return result, nil
+}
+
+func TestFindTokenInfoPages(t *testing.T) {
+	c := &config.Config{}
+	c.UDT.Script.CodeHash = "0x29df6ff32cee9bf58c0504f45da0f2bd3d6fe12b7cab21b785e64a51e7d15745"
+	c.UDT.Script.HashType = "data"
+	c.Info.Script.CodeHash = "0x5f0e2c3ea3bc10c0a1a5c0bb5ca1d2b0ff08cd6c2b0f2a0ae32d3e4c4f1e9a10"
+	c.Info.Script.HashType = "data"
+
+	issuer := &types.Script{CodeHash: types.HexToHash("0x01"), HashType: types.HashTypeType, Args: []byte{1}}
+	uuid, err := issuer.Hash()
+	if err != nil {
+		t.Fatal(err)
+	}
+	infoType, err := InfoTypeScript(c, uuid.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := (&TokenInfo{Decimals: 8, Name: "Token", Symbol: "TKN"}).Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the issuer's cell is the oldest, behind more cells of other locks than fit a page
+	client := &stubCells{}
+	client.cells = append(client.cells, &indexer.LiveCell{
+		OutPoint:   &types.OutPoint{TxHash: types.HexToHash("0x01")},
+		Output:     &types.CellOutput{Lock: issuer, Type: infoType},
+		OutputData: data,
+	})
+	for i := 0; i < 2*infoPageSize+50; i++ {
+		client.cells = append(client.cells, &indexer.LiveCell{
+			OutPoint:   &types.OutPoint{TxHash: types.HexToHash("0x02"), Index: uint(i)},
+			Output:     &types.CellOutput{Lock: &types.Script{CodeHash: types.HexToHash("0x02"), HashType: types.HashTypeType}, Type: infoType},
+			OutputData: []byte{0, 4, 'f', 'a', 'k', 'e', 0},
+		})
+	}
+
+	info, cell, err := FindTokenInfo(client, c, uuid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info == nil || info.Symbol != "TKN" || info.Decimals != 8 || cell.OutPoint.TxHash != types.HexToHash("0x01") {
+		t.Fatalf("expect the issuer's info cell, got %+v", info)
+	}
+	if client.pages != 3 {
+		t.Fatalf("expect 3 pages, got %d", client.pages)
+	}
+
+	client.cells = client.cells[1:]
+	if info, _, err = FindTokenInfo(client, c, uuid); err != nil || info != nil {
+		t.Fatalf("expect no info cell, got %+v, %v", info, err)
+	}
+}
@@ -2,22 +2,29 @@ package cmd
 
 import (
 	"context"
+	"encoding/hex"
 	"fmt"
-	"github.com/nervosnetwork/ckb-sdk-go/crypto/secp256k1"
 	"github.com/nervosnetwork/ckb-sdk-go/indexer"
-	"github.com/nervosnetwork/ckb-sdk-go/rpc"
 	"github.com/nervosnetwork/ckb-sdk-go/transaction"
 	"github.com/nervosnetwork/ckb-sdk-go/types"
 	"github.com/nervosnetwork/ckb-sdk-go/utils"
 	"github.com/ququzone/ckb-udt-cli/config"
 	"github.com/spf13/cobra"
 	"math/big"
+	"strings"
 )
 
 var (
-	issueConf   *string
-	issueKey    *string
-	issueAmount *string
+	issueConf      *string
+	issueKey       *KeyOptions
+	issueAmount    *string
+	issueSend      *SendOptions
+	issueSelection *string
+	issueChain     *bool
+	issueStandard  *string
+	issueFlags     *uint32
+	issueExtension *[]string
+	issueExtDeps   *[]string
 )
 
 var issueCmd = &cobra.Command{
@@ -29,13 +36,39 @@ var issueCmd = &cobra.Command{
 		if err != nil {
 			Fatalf("load config error: %v", err)
 		}
+		if err = c.SelectStandard(*issueStandard); err != nil {
+			Fatalf("token standard error: %v", err)
+		}
+		var extensions []*types.Script
+		for _, s := range *issueExtension {
+			script, err := ParseScript(s)
+			if err != nil {
+				Fatalf("parse extension script error: %v", err)
+			}
+			extensions = append(extensions, script)
+		}
+		var extensionDeps []*types.CellDep
+		for _, s := range *issueExtDeps {
+			depType := types.DepTypeCode
+			if strings.HasSuffix(s, ":dep_group") {
+				s, depType = strings.TrimSuffix(s, ":dep_group"), types.DepTypeDepGroup
+			}
+			outPoint, err := ParseOutPoint(s)
+			if err != nil {
+				Fatalf("parse extension dep error: %v", err)
+			}
+			extensionDeps = append(extensionDeps, &types.CellDep{OutPoint: outPoint, DepType: depType})
+		}
+		if *issueStandard != StandardXUDT && (*issueFlags != 0 || len(extensions) > 0 || len(extensionDeps) > 0) {
+			Fatalf("--xudt-flags, --extension and --extension-dep require --standard xudt")
+		}
 
-		client, err := rpc.DialWithIndexer(c.RPC, c.CkbIndexer)
+		client, err := Dial(c)
 		if err != nil {
 			Fatalf("create rpc client error: %v", err)
 		}
 
-		key, err := secp256k1.HexToKey(*issueKey)
+		key, err := issueKey.Load()
 		if err != nil {
 			Fatalf("import private key error: %v", err)
 		}
@@ -46,15 +79,46 @@ var issueCmd = &cobra.Command{
 		}
 
 		change, err := key.Script(scripts)
+		uuid, _ := change.Hash()
+		typeArgs := uuid.Bytes()
+		if *issueStandard == StandardXUDT {
+			typeArgs, err = XUDTArgs(uuid, *issueFlags, extensions)
+			if err != nil {
+				Fatalf("xUDT args error: %v", err)
+			}
+		}
+		typeScript := &types.Script{
+			CodeHash: types.HexToHash(c.UDT.Script.CodeHash),
+			HashType: types.ScriptHashType(c.UDT.Script.HashType),
+			Args:     typeArgs,
+		}
 
 		capacity := uint64(14200000000)
+		// xUDT args with extension scripts need more than the usual 142 CKB
+		if occupied := (&types.CellOutput{Lock: change, Type: typeScript}).OccupiedCapacity(make([]byte, 16)) * 100000000; occupied > capacity {
+			capacity = occupied
+		}
 		fee := uint64(1000)
 		searchKey := &indexer.SearchKey{
 			Script:     change,
 			ScriptType: "lock",
 		}
-		cellCollector := utils.NewLiveCellCollector(client, searchKey, "asc", 1000, "", utils.NewCapacityLiveCellProcessor(capacity+fee))
-		cells, err := cellCollector.Collect()
+		selector, err := NewCoinSelector(*issueSelection)
+		if err != nil {
+			Fatalf("coin selection error: %v", err)
+		}
+		filter, err := NewOutPointFilter(nil, nil)
+		if err != nil {
+			Fatalf("outpoint filter error: %v", err)
+		}
+		store, err := OpenReservations(client, c, filter)
+		if err != nil {
+			Fatalf("open reservation store error: %v", err)
+		}
+		if *issueChain {
+			store.OfferPending(filter)
+		}
+		cells, err := SelectCapacity(client, searchKey, capacity+fee, selector, filter)
 		if err != nil {
 			Fatalf("collect cell error: %v", err)
 		}
@@ -73,7 +137,12 @@ var issueCmd = &cobra.Command{
 				DepType: types.DepType(dep.DepType),
 			})
 		}
-		uuid, _ := change.Hash()
+		for _, dep := range extensionDeps {
+			// deps under xudt.extensionDeps in config are added already, the node rejects duplicates
+			if !hasCellDep(tx.CellDeps, dep.OutPoint) {
+				tx.CellDeps = append(tx.CellDeps, dep)
+			}
+		}
 
 		tx.Outputs = append(tx.Outputs, &types.CellOutput{
 			Capacity: uint64(capacity),
@@ -82,11 +151,7 @@ var issueCmd = &cobra.Command{
 				HashType: change.HashType,
 				Args:     change.Args,
 			},
-			Type: &types.Script{
-				CodeHash: types.HexToHash(c.UDT.Script.CodeHash),
-				HashType: types.ScriptHashType(c.UDT.Script.HashType),
-				Args:     uuid.Bytes(),
-			},
+			Type: typeScript,
 		})
 		a, _ := big.NewInt(0).SetString(*issueAmount, 10)
 		b := a.Bytes()
@@ -99,7 +164,7 @@ var issueCmd = &cobra.Command{
 			}
 		}
 		tx.OutputsData = append(tx.OutputsData, b)
-		if cells.Capacity-capacity+fee > 6100000000 {
+		if cells.Capacity-capacity-fee >= 6100000000 {
 			tx.Outputs = append(tx.Outputs, &types.CellOutput{
 				Capacity: cells.Capacity - capacity - fee,
 				Lock:     change,
@@ -120,17 +185,37 @@ var issueCmd = &cobra.Command{
 			Fatalf("add inputs to transaction error: %v", err)
 		}
 
+		if err = ValidateTransaction(client, c, tx); err != nil {
+			Fatalf("validate transaction error: %v", err)
+		}
+
 		err = transaction.SingleSignTransaction(tx, group, witnessArgs, key)
 		if err != nil {
 			Fatalf("sign transaction error: %v", err)
 		}
 
+		issueSend.VerifyTransaction(client, c, tx)
+		err = store.Reserve(tx)
+		if err != nil {
+			Fatalf("reserve inputs error: %v", err)
+		}
+		store.Unlock()
+
 		hash, err := client.SendTransaction(context.Background(), tx)
 		if err != nil {
-			Fatalf("send transaction error: %v", err)
+			_ = store.Release(tx)
+			Fatalf("send transaction error: %s", ExplainScriptError(client, c, tx, err))
+		}
+		if err = store.Confirm(tx, *hash); err != nil {
+			fmt.Printf("record reservation error: %v\n", err)
 		}
 
-		fmt.Printf("Issued sUDT transaction hash: %s, uuid: %s\n", hash.String(), uuid.String())
+		if *issueStandard == StandardXUDT {
+			fmt.Printf("Issued xUDT transaction hash: %s, uuid: 0x%s\n", hash.String(), hex.EncodeToString(typeArgs))
+		} else {
+			fmt.Printf("Issued sUDT transaction hash: %s, uuid: %s\n", hash.String(), uuid.String())
+		}
+		issueSend.WaitCommitted(client, hash)
 	},
 }
 
@@ -138,8 +223,14 @@ func init() {
 	rootCmd.AddCommand(issueCmd)
 
 	issueConf = issueCmd.Flags().StringP("config", "c", "config.yaml", "Config file")
-	issueKey = issueCmd.Flags().StringP("key", "k", "", "Issue private key")
+	issueKey = AddKeyFlags(issueCmd.Flags(), "Issue private key")
 	issueAmount = issueCmd.Flags().StringP("amount", "a", "", "Issue amount")
-	_ = issueCmd.MarkFlagRequired("key")
+	issueSelection = issueCmd.Flags().String("coin-selection", OldestFirst, "Coin selection strategy: largest-first, smallest-first, fewest-inputs or oldest-first")
+	issueChain = issueCmd.Flags().Bool("chain-pending", false, "Allow spending outputs of transactions this tool sent that are not committed yet")
+	issueStandard = issueCmd.Flags().String("standard", StandardSUDT, "Token standard: sudt or xudt")
+	issueFlags = issueCmd.Flags().Uint32("xudt-flags", 0, "xUDT flags: 0 for no extension, 1 for extension scripts in args (default 1 with --extension)")
+	issueExtension = issueCmd.Flags().StringArray("extension", nil, "xUDT extension script CODE_HASH:HASH_TYPE:ARGS, repeatable")
+	issueExtDeps = issueCmd.Flags().StringArray("extension-dep", nil, "Cell dep TX_HASH:INDEX[:dep_group] providing an extension script, repeatable")
+	issueSend = AddSendFlags(issueCmd)
 	_ = issueCmd.MarkFlagRequired("amount")
 }
@@ -0,0 +1,298 @@
+package cmd
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+
+	"github.com/nervosnetwork/ckb-sdk-go/address"
+	"github.com/nervosnetwork/ckb-sdk-go/crypto/secp256k1"
+	"github.com/nervosnetwork/ckb-sdk-go/indexer"
+	"github.com/nervosnetwork/ckb-sdk-go/types"
+	"github.com/nervosnetwork/ckb-sdk-go/utils"
+	"github.com/ququzone/ckb-udt-cli/config"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+var (
+	keyNewConf      *string
+	keyNewKeystore  *string
+	keyShowConf     *string
+	keyShowKey      *KeyOptions
+	keyScanConf     *string
+	keyScanMnemonic *string
+	keyScanPath     *string
+	keyScanUUID     *string
+	keyScanCount    *uint32
+)
+
+// KeyOptions holds the flags selecting the signing key of a command: a hex private key, a keystore
+// file or an account derived from a BIP39 mnemonic.
+type KeyOptions struct {
+	Key      *string
+	Keystore *string
+	Mnemonic *string
+	Path     *string
+	Account  *uint32
+}
+
+func AddKeyFlags(flags *pflag.FlagSet, usage string) *KeyOptions {
+	return &KeyOptions{
+		Key:      flags.StringP("key", "k", "", usage),
+		Keystore: flags.String("keystore", "", "Keystore file of the private key"),
+		Mnemonic: flags.String("mnemonic", "", "File holding the BIP39 mnemonic of the private key, - to prompt for it"),
+		Path:     flags.String("hd-path", DefaultDerivationPath, "BIP32 derivation path, the account index is appended"),
+		Account:  flags.Uint32("account", 0, "Account index derived from the mnemonic"),
+	}
+}
+
+// Load returns the private key selected by the flags.
+func (o *KeyOptions) Load() (*secp256k1.Secp256k1Key, error) {
+	switch {
+	case *o.Key != "":
+		return secp256k1.HexToKey(*o.Key)
+	case *o.Keystore != "":
+		return LoadKeystore(*o.Keystore)
+	case *o.Mnemonic != "":
+		derive, err := NewAccountDeriver(*o.Mnemonic, *o.Path)
+		if err != nil {
+			return nil, err
+		}
+		return derive(*o.Account)
+	}
+	return nil, errors.New("one of --key, --keystore or --mnemonic is required")
+}
+
+// NewAccountDeriver reads the mnemonic in file and returns a function deriving the key of an account index under path.
+func NewAccountDeriver(file, path string) (func(account uint32) (*secp256k1.Secp256k1Key, error), error) {
+	parent, err := ParseDerivationPath(path)
+	if err != nil {
+		return nil, err
+	}
+	mnemonic, err := ReadMnemonic(file)
+	if err != nil {
+		return nil, err
+	}
+	seed, err := MnemonicSeed(mnemonic)
+	if err != nil {
+		return nil, err
+	}
+	return func(account uint32) (*secp256k1.Secp256k1Key, error) {
+		if account >= hardenedOffset {
+			return nil, fmt.Errorf("account index %d out of range", account)
+		}
+		return DeriveKey(seed, append(parent[:len(parent):len(parent)], account))
+	}, nil
+}
+
+// printKeyInfo prints the addresses controlled by key and its lock hash, the uuid of sUDT it issues.
+func printKeyInfo(c *config.Config, scripts *utils.SystemScripts, key *secp256k1.Secp256k1Key) {
+	lock, err := key.Script(scripts)
+	if err != nil {
+		Fatalf("load system script error: %v", err)
+	}
+	short, err := address.Generate(address.Testnet, lock)
+	if err != nil {
+		Fatalf("generate address error: %v", err)
+	}
+	full, err := address.GenerateFullPayloadAddress(address.FullTypeFormat, address.Testnet, lock)
+	if err != nil {
+		Fatalf("generate address error: %v", err)
+	}
+	acp, err := address.Generate(address.Testnet, &types.Script{
+		CodeHash: types.HexToHash(c.ACP.Script.CodeHash),
+		HashType: types.ScriptHashType(c.ACP.Script.HashType),
+		Args:     lock.Args,
+	})
+	if err != nil {
+		Fatalf("generate address error: %v", err)
+	}
+	hash, err := lock.Hash()
+	if err != nil {
+		Fatalf("compute lock hash error: %v", err)
+	}
+
+	fmt.Printf("secp256k1 address: %s\n", short)
+	fmt.Printf("secp256k1 full address: %s\n", full)
+	fmt.Printf("anyone can pay address: %s\n", acp)
+	fmt.Printf("lock hash (uuid): %s\n", hash.String())
+}
+
+var keyCmd = &cobra.Command{
+	Use:   "key",
+	Short: "Key utilities",
+	Long:  `Generate keys, show the addresses they control and scan mnemonic accounts.`,
+}
+
+var keyNewCmd = &cobra.Command{
+	Use:   "new",
+	Short: "Generate a random key",
+	Long:  `Generate a random secp256k1 key, printing it or storing it in an encrypted keystore file.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		c, err := config.Init(*keyNewConf)
+		if err != nil {
+			Fatalf("load config error: %v", err)
+		}
+
+		client, err := Dial(c)
+		if err != nil {
+			Fatalf("create rpc client error: %v", err)
+		}
+
+		scripts, err := utils.NewSystemScripts(client)
+		if err != nil {
+			Fatalf("load system script error: %v", err)
+		}
+
+		key, err := secp256k1.RandomNew()
+		if err != nil {
+			Fatalf("generate key error: %v", err)
+		}
+
+		if *keyNewKeystore == "" {
+			fmt.Printf("private key: %s\n", hex.EncodeToString(key.Bytes()))
+		} else {
+			lock, err := key.Script(scripts)
+			if err != nil {
+				Fatalf("load system script error: %v", err)
+			}
+			passphrase, err := ReadPassphrase(true)
+			if err != nil {
+				Fatalf("read passphrase error: %v", err)
+			}
+			data, err := EncryptKey(key.Bytes(), passphrase)
+			if err != nil {
+				Fatalf("encrypt key error: %v", err)
+			}
+			if err = os.MkdirAll(*keyNewKeystore, 0700); err != nil {
+				Fatalf("create keystore error: %v", err)
+			}
+			path := filepath.Join(*keyNewKeystore, hex.EncodeToString(lock.Args)+".json")
+			if _, err = os.Stat(path); err == nil {
+				Fatalf("keystore file %s already exists", path)
+			}
+			if err = ioutil.WriteFile(path, data, 0600); err != nil {
+				Fatalf("write keystore error: %v", err)
+			}
+			fmt.Printf("keystore: %s\n", path)
+		}
+		printKeyInfo(c, scripts, key)
+	},
+}
+
+var keyShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Show addresses of a key",
+	Long:  `Show the secp256k1 and anyone can pay addresses of a key and its lock hash, which is the uuid of sUDT issued by it.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		c, err := config.Init(*keyShowConf)
+		if err != nil {
+			Fatalf("load config error: %v", err)
+		}
+
+		client, err := Dial(c)
+		if err != nil {
+			Fatalf("create rpc client error: %v", err)
+		}
+
+		scripts, err := utils.NewSystemScripts(client)
+		if err != nil {
+			Fatalf("load system script error: %v", err)
+		}
+
+		key, err := keyShowKey.Load()
+		if err != nil {
+			Fatalf("import private key error: %v", err)
+		}
+		printKeyInfo(c, scripts, key)
+	},
+}
+
+var keyScanCmd = &cobra.Command{
+	Use:   "scan",
+	Short: "Find mnemonic accounts holding sUDT",
+	Long:  `Derive accounts from a BIP39 mnemonic and print the indexes whose secp256k1 or anyone can pay cells hold the sUDT.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		c, err := config.Init(*keyScanConf)
+		if err != nil {
+			Fatalf("load config error: %v", err)
+		}
+
+		client, err := Dial(c)
+		if err != nil {
+			Fatalf("create rpc client error: %v", err)
+		}
+
+		scripts, err := utils.NewSystemScripts(client)
+		if err != nil {
+			Fatalf("load system script error: %v", err)
+		}
+
+		derive, err := NewAccountDeriver(*keyScanMnemonic, *keyScanPath)
+		if err != nil {
+			Fatalf("load mnemonic error: %v", err)
+		}
+
+		uuid := types.HexToHash(*keyScanUUID).Bytes()
+		total := big.NewInt(0)
+		for account := uint32(0); account < *keyScanCount; account++ {
+			key, err := derive(account)
+			if err != nil {
+				Fatalf("derive account %d error: %v", account, err)
+			}
+			secpScript, err := key.Script(scripts)
+			if err != nil {
+				Fatalf("load system script error: %v", err)
+			}
+			acpScript := &types.Script{
+				CodeHash: types.HexToHash(c.ACP.Script.CodeHash),
+				HashType: types.ScriptHashType(c.ACP.Script.HashType),
+				Args:     secpScript.Args,
+			}
+			for _, lock := range []*types.Script{secpScript, acpScript} {
+				searchKey := &indexer.SearchKey{
+					Script:     lock,
+					ScriptType: "lock",
+				}
+				cells, err := CollectUDT(client, c, searchKey, "asc", 1000, "", uuid, nil, nil)
+				if err != nil {
+					Fatalf("collect cell error: %v", err)
+				}
+				amount := cells.Options["total"].(*big.Int)
+				if amount.Sign() == 0 {
+					continue
+				}
+				addr, err := address.Generate(address.Testnet, lock)
+				if err != nil {
+					Fatalf("generate address error: %v", err)
+				}
+				total.Add(total, amount)
+				fmt.Printf("account %d address %s amount: %s\n", account, addr, amount.String())
+			}
+		}
+		fmt.Printf("total amount of %d accounts: %s\n", *keyScanCount, total.String())
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(keyCmd)
+	keyCmd.AddCommand(keyNewCmd)
+	keyCmd.AddCommand(keyShowCmd)
+	keyCmd.AddCommand(keyScanCmd)
+
+	keyNewConf = keyNewCmd.Flags().StringP("config", "c", "config.yaml", "Config file")
+	keyNewKeystore = keyNewCmd.Flags().String("keystore", "", "Directory to store the key encrypted with a passphrase instead of printing it")
+	keyShowConf = keyShowCmd.Flags().StringP("config", "c", "config.yaml", "Config file")
+	keyShowKey = AddKeyFlags(keyShowCmd.Flags(), "Private key")
+	keyScanConf = keyScanCmd.Flags().StringP("config", "c", "config.yaml", "Config file")
+	keyScanMnemonic = keyScanCmd.Flags().String("mnemonic", "-", "File holding the BIP39 mnemonic, - to prompt for it")
+	keyScanPath = keyScanCmd.Flags().String("hd-path", DefaultDerivationPath, "BIP32 derivation path, the account index is appended")
+	keyScanUUID = keyScanCmd.Flags().StringP("uuid", "u", "", "UDT uuid")
+	keyScanCount = keyScanCmd.Flags().Uint32("count", 20, "Number of account indexes to scan")
+	_ = keyScanCmd.MarkFlagRequired("uuid")
+}
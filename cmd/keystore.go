@@ -0,0 +1,216 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/nervosnetwork/ckb-sdk-go/crypto/secp256k1"
+	"golang.org/x/crypto/scrypt"
+	"golang.org/x/crypto/sha3"
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+const (
+	keystoreScryptN = 1 << 18
+	keystoreScryptR = 8
+	keystoreScryptP = 1
+	keystoreDKLen   = 32
+)
+
+// keystoreFile is the Web3 Secret Storage v3 layout, encrypted with scrypt and aes-128-ctr.
+type keystoreFile struct {
+	Crypto struct {
+		Cipher       string `json:"cipher"`
+		CipherText   string `json:"ciphertext"`
+		CipherParams struct {
+			IV string `json:"iv"`
+		} `json:"cipherparams"`
+		KDF       string `json:"kdf"`
+		KDFParams struct {
+			DKLen int    `json:"dklen"`
+			N     int    `json:"n"`
+			P     int    `json:"p"`
+			R     int    `json:"r"`
+			Salt  string `json:"salt"`
+		} `json:"kdfparams"`
+		MAC string `json:"mac"`
+	} `json:"crypto"`
+	ID      string `json:"id"`
+	Version int    `json:"version"`
+}
+
+func keystoreMAC(derived, cipherText []byte) []byte {
+	hash := sha3.NewLegacyKeccak256()
+	hash.Write(derived[16:32])
+	hash.Write(cipherText)
+	return hash.Sum(nil)
+}
+
+func aesCTR(key, iv, data []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]byte, len(data))
+	cipher.NewCTR(block, iv).XORKeyStream(result, data)
+	return result, nil
+}
+
+func randomBytes(n int) ([]byte, error) {
+	b := make([]byte, n)
+	_, err := rand.Read(b)
+	return b, err
+}
+
+// EncryptKey returns the keystore JSON of private key encrypted with passphrase.
+func EncryptKey(key []byte, passphrase string) ([]byte, error) {
+	return encryptKey(key, passphrase, keystoreScryptN, keystoreScryptP)
+}
+
+// encryptKey is EncryptKey with the scrypt cost parameters n and p.
+func encryptKey(key []byte, passphrase string, n, p int) ([]byte, error) {
+	salt, err := randomBytes(32)
+	if err != nil {
+		return nil, err
+	}
+	iv, err := randomBytes(16)
+	if err != nil {
+		return nil, err
+	}
+	id, err := randomBytes(16)
+	if err != nil {
+		return nil, err
+	}
+	// random uuid version 4
+	id[6] = id[6]&0x0f | 0x40
+	id[8] = id[8]&0x3f | 0x80
+
+	derived, err := scrypt.Key([]byte(passphrase), salt, n, keystoreScryptR, p, keystoreDKLen)
+	if err != nil {
+		return nil, err
+	}
+	cipherText, err := aesCTR(derived[:16], iv, key)
+	if err != nil {
+		return nil, err
+	}
+
+	var file keystoreFile
+	file.Crypto.Cipher = "aes-128-ctr"
+	file.Crypto.CipherText = hex.EncodeToString(cipherText)
+	file.Crypto.CipherParams.IV = hex.EncodeToString(iv)
+	file.Crypto.KDF = "scrypt"
+	file.Crypto.KDFParams.DKLen = keystoreDKLen
+	file.Crypto.KDFParams.N = n
+	file.Crypto.KDFParams.P = p
+	file.Crypto.KDFParams.R = keystoreScryptR
+	file.Crypto.KDFParams.Salt = hex.EncodeToString(salt)
+	file.Crypto.MAC = hex.EncodeToString(keystoreMAC(derived, cipherText))
+	file.ID = fmt.Sprintf("%x-%x-%x-%x-%x", id[0:4], id[4:6], id[6:8], id[8:10], id[10:])
+	file.Version = 3
+	return json.MarshalIndent(&file, "", "  ")
+}
+
+// DecryptKey returns the private key in keystore JSON data.
+func DecryptKey(data []byte, passphrase string) ([]byte, error) {
+	var file keystoreFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+	if file.Version != 3 || file.Crypto.KDF != "scrypt" || file.Crypto.Cipher != "aes-128-ctr" {
+		return nil, errors.New("unsupported keystore, expect version 3 with scrypt and aes-128-ctr")
+	}
+	params := file.Crypto.KDFParams
+	salt, err := hex.DecodeString(params.Salt)
+	if err != nil {
+		return nil, err
+	}
+	iv, err := hex.DecodeString(file.Crypto.CipherParams.IV)
+	if err != nil {
+		return nil, err
+	}
+	cipherText, err := hex.DecodeString(file.Crypto.CipherText)
+	if err != nil {
+		return nil, err
+	}
+	mac, err := hex.DecodeString(file.Crypto.MAC)
+	if err != nil {
+		return nil, err
+	}
+	if len(iv) != aes.BlockSize {
+		return nil, fmt.Errorf("invalid iv length %d, expect %d", len(iv), aes.BlockSize)
+	}
+	// the first 16 bytes are the aes-128 key and the next 16 authenticate the ciphertext
+	if params.DKLen < 32 {
+		return nil, fmt.Errorf("invalid dklen %d, expect at least 32", params.DKLen)
+	}
+	derived, err := scrypt.Key([]byte(passphrase), salt, params.N, params.R, params.P, params.DKLen)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(keystoreMAC(derived, cipherText), mac) {
+		return nil, errors.New("wrong passphrase")
+	}
+	return aesCTR(derived[:16], iv, cipherText)
+}
+
+var stdinReader = bufio.NewReader(os.Stdin)
+
+// readSecret prompts on stderr and reads a line from stdin without echo when it is a terminal.
+func readSecret(prompt string) (string, error) {
+	fmt.Fprint(os.Stderr, prompt)
+	if fd := int(os.Stdin.Fd()); terminal.IsTerminal(fd) {
+		b, err := terminal.ReadPassword(fd)
+		fmt.Fprintln(os.Stderr)
+		return string(b), err
+	}
+	line, err := stdinReader.ReadString('\n')
+	if err != nil && line == "" {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// ReadPassphrase prompts for a passphrase, asking twice when confirm is set.
+func ReadPassphrase(confirm bool) (string, error) {
+	passphrase, err := readSecret("Passphrase: ")
+	if err != nil {
+		return "", err
+	}
+	if confirm {
+		again, err := readSecret("Repeat passphrase: ")
+		if err != nil {
+			return "", err
+		}
+		if again != passphrase {
+			return "", errors.New("passphrases do not match")
+		}
+	}
+	return passphrase, nil
+}
+
+// LoadKeystore decrypts the key in keystore file path, prompting for its passphrase.
+func LoadKeystore(path string) (*secp256k1.Secp256k1Key, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	passphrase, err := ReadPassphrase(false)
+	if err != nil {
+		return nil, err
+	}
+	key, err := DecryptKey(data, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	return secp256k1.ToKey(key)
+}
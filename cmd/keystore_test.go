@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// testScryptN keeps the tests fast, keystores written by the cli use keystoreScryptN.
+const testScryptN = 1 << 12
+
+func TestKeystoreRoundTrip(t *testing.T) {
+	key := bytes.Repeat([]byte{0x5a}, 32)
+	data, err := encryptKey(key, "correct horse", testScryptN, keystoreScryptP)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := DecryptKey(data, "correct horse")
+	if err != nil {
+		t.Fatalf("decrypt error: %v", err)
+	}
+	if !bytes.Equal(got, key) {
+		t.Fatalf("got %x, want %x", got, key)
+	}
+
+	if _, err = DecryptKey(data, "battery staple"); err == nil || err.Error() != "wrong passphrase" {
+		t.Fatalf("expect wrong passphrase error, got %v", err)
+	}
+}
+
+func TestDecryptKeyInvalidParams(t *testing.T) {
+	data, err := encryptKey(bytes.Repeat([]byte{0x5a}, 32), "correct horse", testScryptN, keystoreScryptP)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		modify func(file *keystoreFile)
+		want   string
+	}{
+		{
+			name:   "short iv",
+			modify: func(file *keystoreFile) { file.Crypto.CipherParams.IV = "00112233" },
+			want:   "invalid iv length 4",
+		},
+		{
+			name:   "long iv",
+			modify: func(file *keystoreFile) { file.Crypto.CipherParams.IV += "00" },
+			want:   "invalid iv length 17",
+		},
+		{
+			name:   "short dklen",
+			modify: func(file *keystoreFile) { file.Crypto.KDFParams.DKLen = 16 },
+			want:   "invalid dklen 16",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var file keystoreFile
+			if err := json.Unmarshal(data, &file); err != nil {
+				t.Fatal(err)
+			}
+			tt.modify(&file)
+			modified, err := json.Marshal(&file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err = DecryptKey(modified, "correct horse"); err == nil || !strings.HasPrefix(err.Error(), tt.want) {
+				t.Fatalf("expect %q error, got %v", tt.want, err)
+			}
+		})
+	}
+}
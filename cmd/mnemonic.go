@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common/math"
+	ethsecp256k1 "github.com/ethereum/go-ethereum/crypto/secp256k1"
+	"github.com/nervosnetwork/ckb-sdk-go/crypto/secp256k1"
+	"github.com/tyler-smith/go-bip39"
+)
+
+const (
+	// DefaultDerivationPath is the CKB BIP44 path of receiving addresses, the account index is appended to it.
+	DefaultDerivationPath = "m/44'/309'/0'/0"
+
+	hardenedOffset = 0x80000000
+)
+
+// ReadMnemonic reads a BIP39 mnemonic from file path, or prompts for it when path is "-", and validates it.
+func ReadMnemonic(path string) (string, error) {
+	var mnemonic string
+	if path == "-" {
+		line, err := readSecret("Mnemonic: ")
+		if err != nil {
+			return "", err
+		}
+		mnemonic = line
+	} else {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return "", err
+		}
+		mnemonic = string(data)
+	}
+	mnemonic = strings.Join(strings.Fields(mnemonic), " ")
+	if !bip39.IsMnemonicValid(mnemonic) {
+		return "", errors.New("invalid mnemonic")
+	}
+	return mnemonic, nil
+}
+
+// MnemonicSeed returns the BIP39 seed of mnemonic with an empty passphrase, as CKB wallets use.
+func MnemonicSeed(mnemonic string) ([]byte, error) {
+	return bip39.NewSeedWithErrorChecking(mnemonic, "")
+}
+
+// ParseDerivationPath parses a BIP32 path like m/44'/309'/0'/0 into child indexes.
+func ParseDerivationPath(path string) ([]uint32, error) {
+	parts := strings.Split(strings.TrimSpace(path), "/")
+	if len(parts) == 0 || parts[0] != "m" {
+		return nil, fmt.Errorf("invalid derivation path %s, must start with m", path)
+	}
+	indexes := make([]uint32, 0, len(parts)-1)
+	for _, part := range parts[1:] {
+		hardened := strings.HasSuffix(part, "'") || strings.HasSuffix(part, "h")
+		if hardened {
+			part = part[:len(part)-1]
+		}
+		index, err := strconv.ParseUint(part, 10, 31)
+		if err != nil {
+			return nil, fmt.Errorf("invalid derivation path %s: %v", path, err)
+		}
+		if hardened {
+			index += hardenedOffset
+		}
+		indexes = append(indexes, uint32(index))
+	}
+	return indexes, nil
+}
+
+// DeriveKey derives the BIP32 private key of seed at path.
+func DeriveKey(seed []byte, path []uint32) (*secp256k1.Secp256k1Key, error) {
+	mac := hmac.New(sha512.New, []byte("Bitcoin seed"))
+	mac.Write(seed)
+	sum := mac.Sum(nil)
+	key, chainCode := sum[:32], sum[32:]
+	n := ethsecp256k1.S256().Params().N
+
+	for _, index := range path {
+		var data []byte
+		if index >= hardenedOffset {
+			data = append([]byte{0}, key...)
+		} else {
+			parent, err := secp256k1.ToKey(key)
+			if err != nil {
+				return nil, err
+			}
+			data = parent.PubKey()
+		}
+		data = append(data, 0, 0, 0, 0)
+		binary.BigEndian.PutUint32(data[len(data)-4:], index)
+
+		mac = hmac.New(sha512.New, chainCode)
+		mac.Write(data)
+		sum = mac.Sum(nil)
+
+		tweak := new(big.Int).SetBytes(sum[:32])
+		if tweak.Cmp(n) >= 0 {
+			return nil, fmt.Errorf("invalid child key at index %d, try the next index", index)
+		}
+		child := tweak.Add(tweak, new(big.Int).SetBytes(key))
+		child.Mod(child, n)
+		if child.Sign() == 0 {
+			return nil, fmt.Errorf("invalid child key at index %d, try the next index", index)
+		}
+		key, chainCode = math.PaddedBigBytes(child, 32), sum[32:]
+	}
+	return secp256k1.ToKey(key)
+}
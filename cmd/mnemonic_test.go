@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/nervosnetwork/ckb-sdk-go/address"
+	"github.com/nervosnetwork/ckb-sdk-go/transaction"
+	"github.com/nervosnetwork/ckb-sdk-go/types"
+	"github.com/nervosnetwork/ckb-sdk-go/utils"
+)
+
+// BIP32 test vector 1, https://github.com/bitcoin/bips/blob/master/bip-0032.mediawiki#test-vector-1
+func TestDeriveKeyBIP32Vector1(t *testing.T) {
+	seed, _ := hex.DecodeString("000102030405060708090a0b0c0d0e0f")
+	tests := []struct {
+		path string
+		key  string
+	}{
+		{"m", "e8f32e723decf4051aefac8e2c93c9c5b214313817cdb01a1494b917c8436b35"},
+		{"m/0'", "edb2e14f9ee77d26dd93b4ecede8d16ed408ce149b6cd80b0715a2d911a0afea"},
+		{"m/0'/1", "3c6cb8d0f6a264c91ea8b5030fadaa8e538b020f0a387421a12de9319dc93368"},
+		{"m/0h/1/2h", "cbce0d719ecf7431d88e6a89fa1483e02e35092af60c042b1df2ff59fa424dca"},
+		{"m/0'/1/2'/2", "0f479245fb19a38a1954c5c7c0ebab2f9bdfd96a17563ef28a6a4b1a2a764ef4"},
+		{"m/0'/1/2'/2/1000000000", "471b76e389e528d6de6d816857e012c5455051cad6660850e58372a6c3e6e7c8"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			path, err := ParseDerivationPath(tt.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			key, err := DeriveKey(seed, path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := hex.EncodeToString(key.Bytes()); got != tt.key {
+				t.Fatalf("got %s, want %s", got, tt.key)
+			}
+		})
+	}
+}
+
+func TestMnemonicAddress(t *testing.T) {
+	mnemonic := "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+	seed, err := MnemonicSeed(mnemonic)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path, err := ParseDerivationPath(DefaultDerivationPath + "/0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := DeriveKey(seed, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lock, err := key.Script(&utils.SystemScripts{
+		SecpSingleSigCell: &utils.SystemScriptCell{CellHash: types.HexToHash(transaction.SECP256K1_BLAKE160_SIGHASH_ALL_TYPE_HASH)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := address.Generate(address.Testnet, lock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "ckt1qyqpjmmvrusl0klsm7q52wdcgqzelt96lsjq3xmkmn"; got != want {
+		t.Fatalf("got %s, want %s", got, want)
+	}
+}
+
+func TestParseDerivationPathInvalid(t *testing.T) {
+	for _, path := range []string{"", "44'/309'", "m/-1", "m/2147483648", "m/x'"} {
+		if _, err := ParseDerivationPath(path); err == nil {
+			t.Errorf("expect error for %q", path)
+		}
+	}
+}
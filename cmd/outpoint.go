@@ -0,0 +1,223 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/nervosnetwork/ckb-sdk-go/indexer"
+	"github.com/nervosnetwork/ckb-sdk-go/types"
+	"github.com/nervosnetwork/ckb-sdk-go/utils"
+	"github.com/spf13/cobra"
+)
+
+// OutPointFilter restricts input selection. Excluded cells are never selected; when any
+// outpoint is pinned only pinned cells are selected and all of them must be spent.
+// Extra holds unconfirmed cells offered as candidates besides the indexer results.
+type OutPointFilter struct {
+	Exclude map[string]bool
+	Use     map[string]bool
+	Extra   []*indexer.LiveCell
+	used    map[string]bool
+}
+
+func NewOutPointFilter(exclude []string, use []string) (*OutPointFilter, error) {
+	f := &OutPointFilter{
+		Exclude: make(map[string]bool),
+		Use:     make(map[string]bool),
+		used:    make(map[string]bool),
+	}
+	excludes, err := expandOutPoints(exclude)
+	if err != nil {
+		return nil, err
+	}
+	for _, o := range excludes {
+		f.Exclude[o] = true
+	}
+	uses, err := expandOutPoints(use)
+	if err != nil {
+		return nil, err
+	}
+	for _, o := range uses {
+		if f.Exclude[o] {
+			return nil, fmt.Errorf("outpoint %s is both excluded and pinned", o)
+		}
+		f.Use[o] = true
+	}
+	return f, nil
+}
+
+func OutPointString(o *types.OutPoint) string {
+	return fmt.Sprintf("%s:%d", o.TxHash.String(), o.Index)
+}
+
+func ParseOutPoint(s string) (*types.OutPoint, error) {
+	parts := strings.Split(strings.TrimSpace(s), ":")
+	if len(parts) != 2 || len(parts[0]) != 66 {
+		return nil, fmt.Errorf("invalid outpoint %s, expect TX_HASH:INDEX", s)
+	}
+	index, err := strconv.ParseUint(parts[1], 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("invalid outpoint index %s", parts[1])
+	}
+	return &types.OutPoint{
+		TxHash: types.HexToHash(parts[0]),
+		Index:  uint(index),
+	}, nil
+}
+
+// expandOutPoints normalizes outpoints, reading values prefixed with @ as files with one outpoint per line.
+func expandOutPoints(values []string) ([]string, error) {
+	var result []string
+	for _, value := range values {
+		if !strings.HasPrefix(value, "@") {
+			o, err := ParseOutPoint(value)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, OutPointString(o))
+			continue
+		}
+		file, err := os.Open(value[1:])
+		if err != nil {
+			return nil, err
+		}
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			o, err := ParseOutPoint(line)
+			if err != nil {
+				_ = file.Close()
+				return nil, err
+			}
+			result = append(result, OutPointString(o))
+		}
+		_ = file.Close()
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+func (f *OutPointFilter) AddExcludes(outPoints []string) {
+	for _, o := range outPoints {
+		f.Exclude[o] = true
+	}
+}
+
+// ExcludeOnly returns a filter keeping the exclusions and extra cells of f without its pinned outpoints.
+func (f *OutPointFilter) ExcludeOnly() *OutPointFilter {
+	return &OutPointFilter{
+		Exclude: f.Exclude,
+		Use:     make(map[string]bool),
+		Extra:   f.Extra,
+		used:    make(map[string]bool),
+	}
+}
+
+func (f *OutPointFilter) Pinned() bool {
+	return f != nil && len(f.Use) > 0
+}
+
+func (f *OutPointFilter) Allow(o *types.OutPoint) bool {
+	if f == nil {
+		return true
+	}
+	key := OutPointString(o)
+	if f.Exclude[key] {
+		return false
+	}
+	return len(f.Use) == 0 || f.Use[key]
+}
+
+// ExtraCells returns Extra cells allowed by the filter that searchKey and typeScript would match.
+// A nil typeScript matches plain cells without data.
+func (f *OutPointFilter) ExtraCells(searchKey *indexer.SearchKey, typeScript *types.Script) []*indexer.LiveCell {
+	if f == nil {
+		return nil
+	}
+	var cells []*indexer.LiveCell
+	for _, cell := range f.Extra {
+		lock := cell.Output.Lock
+		if lock.CodeHash != searchKey.Script.CodeHash || lock.HashType != searchKey.Script.HashType || !bytes.HasPrefix(lock.Args, searchKey.Script.Args) {
+			continue
+		}
+		if typeScript != nil {
+			if !typeScript.Equals(cell.Output.Type) {
+				continue
+			}
+		} else if cell.Output.Type != nil || len(cell.OutputData) > 0 {
+			continue
+		}
+		if f.Allow(cell.OutPoint) {
+			cells = append(cells, cell)
+		}
+	}
+	return cells
+}
+
+func (f *OutPointFilter) MarkUsed(cells []*indexer.LiveCell) {
+	if f == nil {
+		return
+	}
+	for _, cell := range cells {
+		f.used[OutPointString(cell.OutPoint)] = true
+	}
+}
+
+// HasUnusedPins reports whether some pinned outpoints are not selected yet.
+func (f *OutPointFilter) HasUnusedPins() bool {
+	return len(f.Missing()) > 0
+}
+
+// Missing returns pinned outpoints that were not selected.
+func (f *OutPointFilter) Missing() []string {
+	if f == nil {
+		return nil
+	}
+	var missing []string
+	for o := range f.Use {
+		if !f.used[o] {
+			missing = append(missing, o)
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
+
+// FilteredCellProcessor skips cells rejected by Filter before handing them to Processor.
+type FilteredCellProcessor struct {
+	Filter    *OutPointFilter
+	Processor utils.LiveCellProcessor
+}
+
+func (p *FilteredCellProcessor) Process(liveCell *indexer.LiveCell, result *utils.LiveCellCollectResult) (bool, error) {
+	if !p.Filter.Allow(liveCell.OutPoint) {
+		return false, nil
+	}
+	return p.Processor.Process(liveCell, result)
+}
+
+type OutPointFlags struct {
+	Exclude *[]string
+	Use     *[]string
+}
+
+func AddOutPointFlags(cmd *cobra.Command) *OutPointFlags {
+	return &OutPointFlags{
+		Exclude: cmd.Flags().StringArray("exclude-outpoint", nil, "Outpoint TX_HASH:INDEX never used as input, repeatable, @FILE reads one per line"),
+		Use:     cmd.Flags().StringArray("use-outpoint", nil, "Outpoint TX_HASH:INDEX to spend exactly, repeatable, @FILE reads one per line"),
+	}
+}
+
+func (f *OutPointFlags) Filter() (*OutPointFilter, error) {
+	return NewOutPointFilter(*f.Exclude, *f.Use)
+}
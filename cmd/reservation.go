@@ -0,0 +1,307 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/nervosnetwork/ckb-sdk-go/indexer"
+	"github.com/nervosnetwork/ckb-sdk-go/rpc"
+	"github.com/nervosnetwork/ckb-sdk-go/types"
+	"github.com/ququzone/ckb-udt-cli/config"
+)
+
+const (
+	// reservationHold is how long inputs stay reserved before the spending transaction is sent.
+	reservationHold = 5 * time.Minute
+	// reservationMaxAge drops entries whose transaction status can't be resolved for a long time.
+	reservationMaxAge = 24 * time.Hour
+
+	lockRetryInterval = 100 * time.Millisecond
+	lockTimeout       = 30 * time.Second
+)
+
+type reservation struct {
+	TxHash  string `json:"tx_hash,omitempty"`
+	Expires int64  `json:"expires"`
+}
+
+// pendingTransaction keeps the outputs of a sent transaction until the indexer reflects it.
+type pendingTransaction struct {
+	Outputs     []*types.CellOutput `json:"outputs"`
+	OutputsData [][]byte            `json:"outputs_data"`
+	Expires     int64               `json:"expires"`
+}
+
+type reservationFile struct {
+	OutPoints    map[string]*reservation        `json:"outpoints"`
+	Transactions map[string]*pendingTransaction `json:"transactions"`
+}
+
+// ReservationStore records outpoints selected by running commands and transactions they sent,
+// so concurrent invocations on one host don't select the same cells, and cells spent by
+// transactions still in the pool are skipped even though the indexer shows them live.
+type ReservationStore struct {
+	Path     string
+	lockFile *os.File
+	entries  map[string]*reservation
+	pending  map[string]*pendingTransaction
+}
+
+func NewReservationStore(c *config.Config) (*ReservationStore, error) {
+	path := c.Reservations
+	if path == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, err
+		}
+		path = filepath.Join(home, ".ckb-udt-cli", "reservations.json")
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, err
+	}
+	return &ReservationStore{Path: path}, nil
+}
+
+// Lock takes the store file lock and loads the entries. Fatalf releases it on exit, and the
+// system releases it if the process dies, so a lock is never left behind.
+func (s *ReservationStore) Lock() error {
+	lockPath := s.Path + ".lock"
+	file, err := os.OpenFile(lockPath, os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return err
+	}
+	deadline := time.Now().Add(lockTimeout)
+	for tryLockFile(file) != nil {
+		if time.Now().After(deadline) {
+			_ = file.Close()
+			return errors.New("timeout waiting for reservation lock " + lockPath)
+		}
+		time.Sleep(lockRetryInterval)
+	}
+	s.lockFile = file
+	AtExit(s.Unlock)
+
+	s.entries = make(map[string]*reservation)
+	s.pending = make(map[string]*pendingTransaction)
+	data, err := ioutil.ReadFile(s.Path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		s.Unlock()
+		return err
+	}
+	if len(data) > 0 {
+		var file reservationFile
+		if err := json.Unmarshal(data, &file); err != nil {
+			s.Unlock()
+			return err
+		}
+		if file.OutPoints != nil {
+			s.entries = file.OutPoints
+		}
+		if file.Transactions != nil {
+			s.pending = file.Transactions
+		}
+	}
+	return nil
+}
+
+// OpenReservations locks the reservation store and excludes outpoints reserved by other invocations from filter.
+func OpenReservations(client rpc.Client, c *config.Config, filter *OutPointFilter) (*ReservationStore, error) {
+	store, err := NewReservationStore(c)
+	if err != nil {
+		return nil, err
+	}
+	if err := store.LockExcluding(client, filter); err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+// LockExcluding locks and prunes the store, then excludes the outpoints still reserved from filter.
+// A command rebuilding a transaction calls it again to skip cells reserved since it last held the lock.
+func (s *ReservationStore) LockExcluding(client rpc.Client, filter *OutPointFilter) error {
+	if err := s.Lock(); err != nil {
+		return err
+	}
+	reserved, err := s.Prune(client)
+	if err != nil {
+		s.Unlock()
+		return err
+	}
+	filter.AddExcludes(reserved)
+	return nil
+}
+
+// Unlock releases the store file lock. The lock file is kept, removing it would let another
+// invocation lock a new file while a third still waits on the old one.
+func (s *ReservationStore) Unlock() {
+	if s.lockFile == nil {
+		return
+	}
+	_ = unlockFile(s.lockFile)
+	_ = s.lockFile.Close()
+	s.lockFile = nil
+}
+
+func (s *ReservationStore) save() error {
+	data, err := json.MarshalIndent(&reservationFile{OutPoints: s.entries, Transactions: s.pending}, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := s.Path + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.Path)
+}
+
+// Prune drops entries whose hold expired or whose spending transaction left the pool or is
+// indexed, and returns the outpoints that are still reserved. The store must be locked.
+func (s *ReservationStore) Prune(client rpc.Client) ([]string, error) {
+	now := time.Now().Unix()
+	tip, err := client.GetTip(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	// a committed transaction is still pending for selection until the indexer reaches its block
+	alive := make(map[string]bool)
+	isAlive := func(hash string) (bool, error) {
+		if result, ok := alive[hash]; ok {
+			return result, nil
+		}
+		state, err := QueryTransactionState(client, types.HexToHash(hash))
+		if err != nil {
+			return false, err
+		}
+		result := state.Status == types.TransactionStatusPending ||
+			state.Status == types.TransactionStatusProposed ||
+			(state.Status == types.TransactionStatusCommitted && state.BlockNumber > tip.BlockNumber)
+		alive[hash] = result
+		return result, nil
+	}
+
+	var reserved []string
+	for outPoint, entry := range s.entries {
+		if entry.Expires < now {
+			delete(s.entries, outPoint)
+			continue
+		}
+		if entry.TxHash != "" {
+			ok, err := isAlive(entry.TxHash)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				delete(s.entries, outPoint)
+				continue
+			}
+		}
+		reserved = append(reserved, outPoint)
+	}
+	for hash, tx := range s.pending {
+		ok, err := isAlive(hash)
+		if err != nil {
+			return nil, err
+		}
+		if !ok || tx.Expires < now {
+			delete(s.pending, hash)
+		}
+	}
+	return reserved, s.save()
+}
+
+// OfferPending adds unspent outputs of transactions still in the pool to filter as extra
+// candidates, so a new transaction can chain on them. The store must be pruned first.
+func (s *ReservationStore) OfferPending(filter *OutPointFilter) {
+	for hash, tx := range s.pending {
+		for i, output := range tx.Outputs {
+			filter.Extra = append(filter.Extra, &indexer.LiveCell{
+				BlockNumber: math.MaxUint64,
+				OutPoint: &types.OutPoint{
+					TxHash: types.HexToHash(hash),
+					Index:  uint(i),
+				},
+				Output:     output,
+				OutputData: tx.OutputsData[i],
+			})
+		}
+	}
+}
+
+// Reserve holds the inputs of tx until Confirm or Release. The store must be locked.
+func (s *ReservationStore) Reserve(tx *types.Transaction) error {
+	expires := time.Now().Add(reservationHold).Unix()
+	for _, input := range tx.Inputs {
+		s.entries[OutPointString(input.PreviousOutput)] = &reservation{Expires: expires}
+	}
+	return s.save()
+}
+
+// Confirm records hash as the spender of the inputs of tx, keeping them reserved while it is pending.
+func (s *ReservationStore) Confirm(tx *types.Transaction, hash types.Hash) error {
+	return s.update(func() {
+		expires := time.Now().Add(reservationMaxAge).Unix()
+		for _, input := range tx.Inputs {
+			s.entries[OutPointString(input.PreviousOutput)] = &reservation{TxHash: hash.String(), Expires: expires}
+		}
+		s.pending[hash.String()] = &pendingTransaction{
+			Outputs:     tx.Outputs,
+			OutputsData: tx.OutputsData,
+			Expires:     expires,
+		}
+	})
+}
+
+// Release drops the reservations of the inputs of tx.
+func (s *ReservationStore) Release(tx *types.Transaction) error {
+	return s.update(func() {
+		for _, input := range tx.Inputs {
+			delete(s.entries, OutPointString(input.PreviousOutput))
+		}
+	})
+}
+
+func (s *ReservationStore) update(f func()) error {
+	if s.lockFile == nil {
+		if err := s.Lock(); err != nil {
+			return err
+		}
+		defer s.Unlock()
+	}
+	f()
+	return s.save()
+}
+
+// Sent reports whether hash is a transaction recorded by Confirm that is still pending. The store must be pruned first.
+func (s *ReservationStore) Sent(hash types.Hash) bool {
+	_, ok := s.pending[hash.String()]
+	return ok
+}
+
+// SpentOutputs reports whether a reserved input spends an output of hash. The store must be pruned first.
+func (s *ReservationStore) SpentOutputs(hash types.Hash) bool {
+	prefix := hash.String() + ":"
+	for outPoint := range s.entries {
+		if strings.HasPrefix(outPoint, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Replace records hash as the spender of the inputs of tx in place of the pending transaction old.
+func (s *ReservationStore) Replace(old types.Hash, tx *types.Transaction, hash types.Hash) error {
+	if err := s.update(func() { delete(s.pending, old.String()) }); err != nil {
+		return err
+	}
+	return s.Confirm(tx, hash)
+}
@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestReservationLockExclusive(t *testing.T) {
+	dir, err := ioutil.TempDir("", "reservations")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "reservations.json")
+
+	holder := &ReservationStore{Path: path}
+	if err := holder.Lock(); err != nil {
+		t.Fatal(err)
+	}
+	acquired := make(chan time.Time)
+	go func() {
+		waiter := &ReservationStore{Path: path}
+		if err := waiter.Lock(); err != nil {
+			t.Error(err)
+		}
+		acquired <- time.Now()
+		waiter.Unlock()
+	}()
+
+	time.Sleep(5 * lockRetryInterval)
+	released := time.Now()
+	holder.Unlock()
+	if at := <-acquired; at.Before(released) {
+		t.Fatal("second store locked while the first held the lock")
+	}
+}
@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/nervosnetwork/ckb-sdk-go/rpc"
+	"github.com/nervosnetwork/ckb-sdk-go/types"
+	"github.com/nervosnetwork/ckb-sdk-go/utils"
+	"github.com/ququzone/ckb-udt-cli/config"
+)
+
+// error codes shared by the system scripts, sUDT, xUDT and anyone can pay lock
+var commonScriptErrors = map[int]string{
+	-1:  "invalid script args length",
+	-2:  "invalid data encoding",
+	-3:  "syscall failed",
+	-21: "script too long",
+}
+
+var secpScriptErrors = map[int]string{
+	-11: "failed to recover public key from signature",
+	-12: "signature verification failed",
+	-13: "failed to parse public key",
+	-14: "failed to parse signature",
+	-15: "failed to serialize public key",
+	-22: "witness too short, the lock field must hold a 65 byte signature",
+	-31: "signature does not match the public key hash in lock args, signed with a wrong key",
+}
+
+var udtScriptErrors = map[int]string{
+	-51: "sUDT amount overflows uint128",
+	-52: "sUDT outputs exceed inputs, only the owner lock may mint",
+}
+
+// xUDT keeps the sUDT amount codes, its own and its extensions' codes are not decoded
+var xudtScriptErrors = map[int]string{
+	-51: "xUDT amount overflows uint128",
+	-52: "xUDT outputs exceed inputs, only the owner lock may mint",
+}
+
+// anyone can pay lock codes, it falls back to secp256k1 signature verification for the rest
+var acpScriptErrors = map[int]string{
+	-41: "amount overflows",
+	-42: "output amount is less than the input amount, or the payment is below the minimum in lock args",
+	-43: "more anyone can pay outputs than inputs with the same lock",
+	-44: "anyone can pay input has no output with the same lock and type",
+	-45: "several anyone can pay inputs with the same lock and type",
+	-46: "several anyone can pay outputs with the same lock and type",
+}
+
+// ExplainScriptError decodes a script failure in err into a readable message naming the faulting
+// cell of tx. Other errors are returned as they are.
+func ExplainScriptError(client rpc.Client, c *config.Config, tx *types.Transaction, err error) string {
+	e, ok := ParseScriptError(err)
+	if !ok {
+		return err.Error()
+	}
+
+	var script *types.Script
+	if e.Input {
+		if inputs, resolveErr := ResolveInputs(client, tx); resolveErr == nil && e.Index < len(inputs) && inputs[e.Index] != nil {
+			script = inputs[e.Index].Output.Lock
+			if e.Script == "type" {
+				script = inputs[e.Index].Output.Type
+			}
+		}
+	} else if e.Index < len(tx.Outputs) {
+		script = tx.Outputs[e.Index].Lock
+		if e.Script == "type" {
+			script = tx.Outputs[e.Index].Type
+		}
+	}
+
+	name, unknown, codes := "script", "unknown error", []map[int]string(nil)
+	switch {
+	case script == nil:
+	case script.CodeHash == types.HexToHash(c.ACP.Script.CodeHash):
+		name, codes = "anyone can pay lock", []map[int]string{acpScriptErrors, secpScriptErrors, commonScriptErrors}
+	case IsXUDTScript(c, script):
+		name, codes = "xUDT type script", []map[int]string{xudtScriptErrors, commonScriptErrors}
+		unknown = "undecoded xUDT or extension script error"
+	case IsUDTScript(c, script):
+		name, codes = "sUDT type script", []map[int]string{udtScriptErrors, commonScriptErrors}
+	default:
+		if scripts, scriptsErr := utils.NewSystemScripts(client); scriptsErr == nil && script.CodeHash == scripts.SecpSingleSigCell.CellHash {
+			name, codes = "secp256k1 lock", []map[int]string{secpScriptErrors, commonScriptErrors}
+		}
+	}
+	meaning := unknown
+	for _, m := range codes {
+		if s, ok := m[e.Code]; ok {
+			meaning = s
+			break
+		}
+	}
+	return fmt.Sprintf("%s error %d at %s: %s\n%v", name, e.Code, e.Describe(tx), meaning, err)
+}
@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/nervosnetwork/ckb-sdk-go/types"
+	"github.com/ququzone/ckb-udt-cli/config"
+)
+
+func TestExplainScriptErrorCodes(t *testing.T) {
+	c := &config.Config{}
+	c.ACP.Script.CodeHash = "0x86a1c6987a4acbe1a887cca4c9dd2ac9fcb07405bbeda51b861b18bbf7492c4b"
+	c.ACP.Script.HashType = "type"
+	c.UDT.Script.CodeHash = "0x48dbf59b4c7ee1547238021b4869bceedf4eea6b43772e5d66ef8865b6ae7212"
+	c.UDT.Script.HashType = "data"
+	c.XUDT.Script.CodeHash = "0x50bd8d6680b8b9cf98b73f3c08faf8b2a21914311954118ad6609be6e78a1b95"
+	c.XUDT.Script.HashType = "data"
+	script := func(codeHash string, hashType string) *types.Script {
+		return &types.Script{CodeHash: types.HexToHash(codeHash), HashType: types.ScriptHashType(hashType)}
+	}
+	acp := script(c.ACP.Script.CodeHash, c.ACP.Script.HashType)
+
+	tests := []struct {
+		name   string
+		output *types.CellOutput
+		source string
+		code   int
+		kind   string
+		want   string
+	}{
+		{"acp code", &types.CellOutput{Lock: acp}, "Lock", -42, "anyone can pay lock", "output amount is less than the input amount"},
+		{"acp signature", &types.CellOutput{Lock: acp}, "Lock", -12, "anyone can pay lock", "signature verification failed"},
+		{"acp common", &types.CellOutput{Lock: acp}, "Lock", -1, "anyone can pay lock", "invalid script args length"},
+		{"sudt", &types.CellOutput{Lock: acp, Type: script(c.UDT.Script.CodeHash, c.UDT.Script.HashType)}, "Type", -52, "sUDT type script", "sUDT outputs exceed inputs"},
+		{"xudt", &types.CellOutput{Lock: acp, Type: script(c.XUDT.Script.CodeHash, c.XUDT.Script.HashType)}, "Type", -52, "xUDT type script", "xUDT outputs exceed inputs"},
+		{"xudt extension", &types.CellOutput{Lock: acp, Type: script(c.XUDT.Script.CodeHash, c.XUDT.Script.HashType)}, "Type", 53, "xUDT type script", "undecoded xUDT or extension script error"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tx := &types.Transaction{Outputs: []*types.CellOutput{tt.output}, OutputsData: [][]byte{{}}}
+			err := errors.New("TransactionFailedToVerify: Verification failed Script(TransactionScriptError { source: Outputs[0]." + tt.source + ", cause: ValidationFailure(" + strconv.Itoa(tt.code) + ") })")
+			got := ExplainScriptError(nil, c, tx, err)
+			if !strings.HasPrefix(got, tt.kind+" error ") || !strings.Contains(got, "): "+tt.want) {
+				t.Fatalf("got %q, want %s error: %s", got, tt.kind, tt.want)
+			}
+		})
+	}
+}
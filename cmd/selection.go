@@ -0,0 +1,196 @@
+package cmd
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/nervosnetwork/ckb-sdk-go/indexer"
+	"github.com/nervosnetwork/ckb-sdk-go/rpc"
+	"github.com/nervosnetwork/ckb-sdk-go/utils"
+	"github.com/ququzone/ckb-udt-cli/config"
+)
+
+const (
+	LargestFirst  = "largest-first"
+	SmallestFirst = "smallest-first"
+	FewestInputs  = "fewest-inputs"
+	OldestFirst   = "oldest-first"
+)
+
+// CellValue returns the amount a cell contributes to a selection target.
+type CellValue func(cell *indexer.LiveCell) *big.Int
+
+// CoinSelector picks cells whose values cover target.
+// When all cells together can't cover target every cell is returned.
+type CoinSelector interface {
+	Select(cells []*indexer.LiveCell, value CellValue, target *big.Int) []*indexer.LiveCell
+}
+
+func NewCoinSelector(name string) (CoinSelector, error) {
+	switch name {
+	case LargestFirst:
+		return &sortedSelector{less: func(a, b *big.Int) bool { return a.Cmp(b) > 0 }}, nil
+	case SmallestFirst:
+		return &sortedSelector{less: func(a, b *big.Int) bool { return a.Cmp(b) < 0 }}, nil
+	case FewestInputs:
+		return &fewestInputsSelector{}, nil
+	case OldestFirst:
+		return &oldestFirstSelector{}, nil
+	}
+	return nil, fmt.Errorf("unknown coin selection strategy: %s", name)
+}
+
+func UDTValue(cell *indexer.LiveCell) *big.Int {
+	amount, err := ParseUDTAmount(cell.OutputData)
+	if err != nil {
+		return big.NewInt(0)
+	}
+	return amount
+}
+
+func CapacityValue(cell *indexer.LiveCell) *big.Int {
+	return big.NewInt(0).SetUint64(cell.Output.Capacity)
+}
+
+// takeUntil returns the prefix of cells whose values first reach target.
+func takeUntil(cells []*indexer.LiveCell, value CellValue, target *big.Int) []*indexer.LiveCell {
+	total := big.NewInt(0)
+	for i, cell := range cells {
+		total.Add(total, value(cell))
+		if total.Cmp(target) >= 0 {
+			return cells[:i+1]
+		}
+	}
+	return cells
+}
+
+type sortedSelector struct {
+	less func(a, b *big.Int) bool
+}
+
+func (s *sortedSelector) Select(cells []*indexer.LiveCell, value CellValue, target *big.Int) []*indexer.LiveCell {
+	sorted := append([]*indexer.LiveCell(nil), cells...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return s.less(value(sorted[i]), value(sorted[j]))
+	})
+	return takeUntil(sorted, value, target)
+}
+
+type oldestFirstSelector struct{}
+
+func (s *oldestFirstSelector) Select(cells []*indexer.LiveCell, value CellValue, target *big.Int) []*indexer.LiveCell {
+	sorted := append([]*indexer.LiveCell(nil), cells...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, b := sorted[i], sorted[j]
+		if a.BlockNumber != b.BlockNumber {
+			return a.BlockNumber < b.BlockNumber
+		}
+		if a.TxIndex != b.TxIndex {
+			return a.TxIndex < b.TxIndex
+		}
+		return a.OutPoint.Index < b.OutPoint.Index
+	})
+	return takeUntil(sorted, value, target)
+}
+
+// fewestInputsSelector finds the smallest number of inputs covering target, then swaps
+// chosen cells for smaller unchosen ones while the target stays covered to reduce the excess.
+type fewestInputsSelector struct{}
+
+func (s *fewestInputsSelector) Select(cells []*indexer.LiveCell, value CellValue, target *big.Int) []*indexer.LiveCell {
+	sorted := append([]*indexer.LiveCell(nil), cells...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return value(sorted[i]).Cmp(value(sorted[j])) > 0
+	})
+	chosen := takeUntil(sorted, value, target)
+	if len(chosen) == len(sorted) {
+		return chosen
+	}
+
+	selected := append([]*indexer.LiveCell(nil), chosen...)
+	rest := append([]*indexer.LiveCell(nil), sorted[len(chosen):]...)
+	total := big.NewInt(0)
+	for _, cell := range selected {
+		total.Add(total, value(cell))
+	}
+	for i := len(selected) - 1; i >= 0; i-- {
+		current := value(selected[i])
+		// rest is sorted descending, so the last candidate that still covers target is the smallest one
+		best := -1
+		for j, candidate := range rest {
+			v := value(candidate)
+			if v.Cmp(current) >= 0 {
+				continue
+			}
+			next := big.NewInt(0).Sub(total, current)
+			if next.Add(next, v).Cmp(target) >= 0 {
+				best = j
+			}
+		}
+		if best < 0 {
+			continue
+		}
+		total.Sub(total, current)
+		total.Add(total, value(rest[best]))
+		selected[i], rest[best] = rest[best], selected[i]
+		sort.SliceStable(rest, func(a, b int) bool {
+			return value(rest[a]).Cmp(value(rest[b])) > 0
+		})
+	}
+	return selected
+}
+
+func newCollectResult(cells []*indexer.LiveCell, value CellValue) *utils.LiveCellCollectResult {
+	result := &utils.LiveCellCollectResult{
+		LiveCells: cells,
+		Options:   make(map[string]interface{}),
+	}
+	total := big.NewInt(0)
+	for _, cell := range cells {
+		result.Capacity += cell.Output.Capacity
+		if value != nil {
+			total.Add(total, value(cell))
+		}
+	}
+	result.Options["total"] = total
+	return result
+}
+
+// SelectUDT collects every sUDT cell matching searchKey and picks the inputs covering target with selector.
+// Pinned outpoints in filter bypass the selector and are all returned.
+func SelectUDT(client rpc.Client, c *config.Config, searchKey *indexer.SearchKey, uuid []byte, target *big.Int, selector CoinSelector, filter *OutPointFilter) (*utils.LiveCellCollectResult, error) {
+	cells, err := CollectUDT(client, c, searchKey, "asc", 1000, "", uuid, nil, filter)
+	if err != nil {
+		return nil, err
+	}
+	candidates := append(cells.LiveCells, filter.ExtraCells(searchKey, UDTTypeScript(c, uuid))...)
+	selected := candidates
+	if !filter.Pinned() {
+		selected = selector.Select(candidates, UDTValue, target)
+	}
+	filter.MarkUsed(selected)
+	return newCollectResult(selected, UDTValue), nil
+}
+
+// SelectCapacity collects every plain cell matching searchKey and picks the inputs covering target with selector.
+// Pinned outpoints in filter bypass the selector and are all returned.
+func SelectCapacity(client rpc.Client, searchKey *indexer.SearchKey, target uint64, selector CoinSelector, filter *OutPointFilter) (*utils.LiveCellCollectResult, error) {
+	var processor utils.LiveCellProcessor = utils.NewCapacityLiveCellProcessor(0)
+	if filter != nil {
+		processor = &FilteredCellProcessor{Filter: filter, Processor: processor}
+	}
+	cellCollector := utils.NewLiveCellCollector(client, searchKey, "asc", 1000, "", processor)
+	cellCollector.EmptyData = true
+	cells, err := cellCollector.Collect()
+	if err != nil {
+		return nil, err
+	}
+	candidates := append(cells.LiveCells, filter.ExtraCells(searchKey, nil)...)
+	selected := candidates
+	if !filter.Pinned() {
+		selected = selector.Select(candidates, CapacityValue, big.NewInt(0).SetUint64(target))
+	}
+	filter.MarkUsed(selected)
+	return newCollectResult(selected, nil), nil
+}
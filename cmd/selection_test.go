@@ -0,0 +1,174 @@
+package cmd
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"math/rand"
+	"testing"
+
+	"github.com/nervosnetwork/ckb-sdk-go/indexer"
+	"github.com/nervosnetwork/ckb-sdk-go/transaction"
+	"github.com/nervosnetwork/ckb-sdk-go/types"
+	"github.com/nervosnetwork/ckb-sdk-go/utils"
+)
+
+var (
+	benchLock = &types.Script{
+		CodeHash: types.HexToHash("0x9bd7e06f3ecf4be0f2fcd2188b23f1b9fcc88e5d4b65a8637b17723bbda3cce8"),
+		HashType: types.HashTypeType,
+		Args:     make([]byte, 20),
+	}
+	benchType = &types.Script{
+		CodeHash: types.HexToHash("0x48dbf59b4c7ee1547238021b4869bceedf4eea6b43772e5d66ef8865b6ae7212"),
+		HashType: types.HashTypeData,
+		Args:     make([]byte, 32),
+	}
+)
+
+// benchCells returns sUDT cells holding amounts, one per transaction, in block order.
+func benchCells(amounts []uint64) []*indexer.LiveCell {
+	cells := make([]*indexer.LiveCell, 0, len(amounts))
+	for i, amount := range amounts {
+		var hash types.Hash
+		binary.LittleEndian.PutUint64(hash[:], uint64(i))
+		data, _ := UpdateSudtAmount(make([]byte, 16), big.NewInt(0).SetUint64(amount))
+		cells = append(cells, &indexer.LiveCell{
+			BlockNumber: uint64(i),
+			OutPoint:    &types.OutPoint{TxHash: hash},
+			Output:      &types.CellOutput{Capacity: 14200000000, Lock: benchLock, Type: benchType},
+			OutputData:  data,
+		})
+	}
+	return cells
+}
+
+func TestCoinSelection(t *testing.T) {
+	// cells in block order, so oldest-first takes them as listed
+	cells := benchCells([]uint64{50, 10, 30, 5, 70})
+	tests := []struct {
+		strategy string
+		target   int64
+		want     []uint64
+	}{
+		{LargestFirst, 60, []uint64{70}},
+		{LargestFirst, 100, []uint64{70, 50}},
+		{SmallestFirst, 60, []uint64{5, 10, 30, 50}},
+		{OldestFirst, 60, []uint64{50, 10}},
+		{OldestFirst, 85, []uint64{50, 10, 30}},
+		{FewestInputs, 60, []uint64{70}},
+		// one input covers 45, so the 70 cell is swapped for the smaller 50 one
+		{FewestInputs, 45, []uint64{50}},
+		// two inputs are needed, the 50 cell is swapped for the 30 one since 70 and 30 still cover 95
+		{FewestInputs, 95, []uint64{70, 30}},
+		{LargestFirst, 1000, []uint64{70, 50, 30, 10, 5}},
+		{OldestFirst, 1000, []uint64{50, 10, 30, 5, 70}},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s %d", tt.strategy, tt.target), func(t *testing.T) {
+			selector, err := NewCoinSelector(tt.strategy)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []uint64
+			for _, cell := range selector.Select(cells, UDTValue, big.NewInt(tt.target)) {
+				got = append(got, UDTValue(cell).Uint64())
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// dustCells are 2000 cells of at most 10 tokens interleaved with a few large ones.
+func dustCells() []*indexer.LiveCell {
+	random := rand.New(rand.NewSource(1))
+	amounts := make([]uint64, 0, 2010)
+	for i := 0; i < 2000; i++ {
+		amounts = append(amounts, uint64(random.Intn(10)+1))
+		if i%200 == 199 {
+			amounts = append(amounts, 5000)
+		}
+	}
+	return benchCells(amounts)
+}
+
+// mixedCells are 500 cells spread over six orders of magnitude.
+func mixedCells() []*indexer.LiveCell {
+	random := rand.New(rand.NewSource(2))
+	amounts := make([]uint64, 0, 500)
+	for i := 0; i < 500; i++ {
+		scale := uint64(1)
+		for j := random.Intn(6); j > 0; j-- {
+			scale *= 10
+		}
+		amounts = append(amounts, uint64(random.Intn(9)+1)*scale)
+	}
+	return benchCells(amounts)
+}
+
+// benchTxSize returns the serialized size of a signed transfer spending cells.
+func benchTxSize(b *testing.B, cells []*indexer.LiveCell) int {
+	tx := transaction.NewSecp256k1SingleSigTx(&utils.SystemScripts{
+		SecpSingleSigCell: &utils.SystemScriptCell{OutPoint: &types.OutPoint{}},
+	})
+	tx.CellDeps = append(tx.CellDeps, &types.CellDep{OutPoint: &types.OutPoint{}, DepType: types.DepTypeCode})
+	for i := 0; i < 2; i++ {
+		tx.Outputs = append(tx.Outputs, &types.CellOutput{Capacity: 14200000000, Lock: benchLock, Type: benchType})
+		tx.OutputsData = append(tx.OutputsData, make([]byte, 16))
+	}
+	var inputs []*types.CellInput
+	for _, cell := range cells {
+		inputs = append(inputs, &types.CellInput{PreviousOutput: cell.OutPoint})
+	}
+	if _, _, err := transaction.AddInputsForTransaction(tx, inputs); err != nil {
+		b.Fatal(err)
+	}
+	raw, err := tx.Serialize()
+	if err != nil {
+		b.Fatal(err)
+	}
+	witnesses := make([][]byte, 0, len(tx.Witnesses))
+	for _, witness := range tx.Witnesses {
+		witnesses = append(witnesses, types.SerializeBytes(witness))
+	}
+	// a block stores the transaction behind a 4 byte offset
+	return len(types.SerializeTable([][]byte{raw, types.SerializeDynVec(witnesses)})) + 4
+}
+
+func BenchmarkCoinSelection(b *testing.B) {
+	sets := []struct {
+		name   string
+		cells  []*indexer.LiveCell
+		target *big.Int
+	}{
+		{"dust", dustCells(), big.NewInt(12000)},
+		{"mixed", mixedCells(), big.NewInt(1500000)},
+	}
+	strategies := []string{OldestFirst, LargestFirst, SmallestFirst, FewestInputs}
+	for _, set := range sets {
+		for _, strategy := range strategies {
+			selector, err := NewCoinSelector(strategy)
+			if err != nil {
+				b.Fatal(err)
+			}
+			b.Run(set.name+"/"+strategy, func(b *testing.B) {
+				var selected []*indexer.LiveCell
+				for i := 0; i < b.N; i++ {
+					selected = selector.Select(set.cells, UDTValue, set.target)
+				}
+				b.StopTimer()
+				total := big.NewInt(0)
+				for _, cell := range selected {
+					total.Add(total, UDTValue(cell))
+				}
+				if total.Cmp(set.target) < 0 {
+					b.Fatalf("selected %s, less than target %s", total, set.target)
+				}
+				b.ReportMetric(float64(len(selected)), "inputs")
+				b.ReportMetric(float64(benchTxSize(b, selected)), "tx-bytes")
+			})
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+
+	"github.com/nervosnetwork/ckb-sdk-go/address"
+	"github.com/nervosnetwork/ckb-sdk-go/types"
+	"github.com/nervosnetwork/ckb-sdk-go/utils"
+	"github.com/ququzone/ckb-udt-cli/config"
+	"github.com/spf13/cobra"
+)
+
+var (
+	supplyConf     *string
+	supplyUUID     *string
+	supplyTreasury *[]string
+	supplyCustody  *[]string
+	supplyJSON     *bool
+)
+
+// SupplyReport splits the total minted sUDT into the amounts held by the issuer, treasury and custody
+// addresses and the circulating remainder.
+type SupplyReport struct {
+	UUID           string `json:"uuid"`
+	TipBlockNumber uint64 `json:"tipBlockNumber"`
+	TipBlockHash   string `json:"tipBlockHash"`
+	TotalSupply    string `json:"totalSupply"`
+	Issuer         string `json:"issuer"`
+	Treasury       string `json:"treasury"`
+	Custody        string `json:"custody"`
+	Circulating    string `json:"circulating"`
+	Holders        int    `json:"holders"`
+}
+
+// lockHashes returns the lock hashes of addrs.
+func lockHashes(addrs []string) ([]types.Hash, error) {
+	hashes := make([]types.Hash, 0, len(addrs))
+	for _, addr := range addrs {
+		parsed, err := address.Parse(addr)
+		if err != nil {
+			return nil, fmt.Errorf("parse address %s error: %v", addr, err)
+		}
+		hash, err := parsed.Script.Hash()
+		if err != nil {
+			return nil, err
+		}
+		hashes = append(hashes, hash)
+	}
+	return hashes, nil
+}
+
+var supplyCmd = &cobra.Command{
+	Use:   "supply",
+	Short: "Report sUDT circulating supply",
+	Long:  `Report the total minted sUDT, the amounts held by the issuer lock and its anyone can pay cells, by treasury and custody addresses, and the circulating remainder.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		c, err := config.Init(*supplyConf)
+		if err != nil {
+			Fatalf("load config error: %v", err)
+		}
+
+		uuid := types.HexToHash(*supplyUUID)
+		treasury, err := lockHashes(append(c.Supply.Treasury, *supplyTreasury...))
+		if err != nil {
+			Fatalf("load treasury addresses error: %v", err)
+		}
+		custody, err := lockHashes(append(c.Supply.Custody, *supplyCustody...))
+		if err != nil {
+			Fatalf("load custody addresses error: %v", err)
+		}
+		// every lock is counted once, the issuer first, then treasury and custody
+		categories := map[types.Hash]string{uuid: "issuer"}
+		for _, hash := range treasury {
+			if _, ok := categories[hash]; !ok {
+				categories[hash] = "treasury"
+			}
+		}
+		for _, hash := range custody {
+			if _, ok := categories[hash]; !ok {
+				categories[hash] = "custody"
+			}
+		}
+
+		client, err := Dial(c)
+		if err != nil {
+			Fatalf("create rpc client error: %v", err)
+		}
+
+		snapshot, err := CollectHolders(client, c, uuid)
+		if err != nil {
+			Fatalf("collect holders error: %v", err)
+		}
+
+		scripts, err := utils.NewSystemScripts(client)
+		if err != nil {
+			Fatalf("load system script error: %v", err)
+		}
+		// anyone can pay cells of the issuer's key are held by the issuer too
+		acpCodeHash := types.HexToHash(c.ACP.Script.CodeHash)
+		for _, holder := range snapshot.Holders {
+			if holder.Lock.CodeHash != acpCodeHash || len(holder.Lock.Args) < 20 {
+				continue
+			}
+			secpScript := &types.Script{
+				CodeHash: scripts.SecpSingleSigCell.CellHash,
+				HashType: types.HashTypeType,
+				Args:     holder.Lock.Args[:20],
+			}
+			if hash, err := secpScript.Hash(); err == nil && hash == uuid {
+				categories[holder.LockHash] = "issuer"
+			}
+		}
+
+		amounts := map[string]*big.Int{
+			"issuer":   big.NewInt(0),
+			"treasury": big.NewInt(0),
+			"custody":  big.NewInt(0),
+		}
+		circulating := new(big.Int).Set(snapshot.Total)
+		for _, holder := range snapshot.Holders {
+			if category, ok := categories[holder.LockHash]; ok {
+				amounts[category].Add(amounts[category], holder.Amount)
+				circulating.Sub(circulating, holder.Amount)
+			}
+		}
+
+		report := &SupplyReport{
+			UUID:           uuid.String(),
+			TipBlockNumber: snapshot.Tip.BlockNumber,
+			TipBlockHash:   snapshot.Tip.BlockHash.String(),
+			TotalSupply:    snapshot.Total.String(),
+			Issuer:         amounts["issuer"].String(),
+			Treasury:       amounts["treasury"].String(),
+			Custody:        amounts["custody"].String(),
+			Circulating:    circulating.String(),
+			Holders:        len(snapshot.Holders),
+		}
+		if *supplyJSON {
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			if err = encoder.Encode(report); err != nil {
+				Fatalf("encode report error: %v", err)
+			}
+			return
+		}
+		fmt.Printf("uuid: %s\n", report.UUID)
+		fmt.Printf("tip block: %d %s\n", report.TipBlockNumber, report.TipBlockHash)
+		fmt.Printf("total supply: %s\n", report.TotalSupply)
+		fmt.Printf("issuer: %s\n", report.Issuer)
+		fmt.Printf("treasury: %s\n", report.Treasury)
+		fmt.Printf("custody: %s\n", report.Custody)
+		fmt.Printf("circulating supply: %s\n", report.Circulating)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(supplyCmd)
+
+	supplyConf = supplyCmd.Flags().StringP("config", "c", "config.yaml", "Config file")
+	supplyUUID = supplyCmd.Flags().StringP("uuid", "u", "", "UDT uuid")
+	supplyTreasury = supplyCmd.Flags().StringArray("treasury", nil, "Treasury address excluded from the circulating supply, added to supply.treasury in config, repeatable")
+	supplyCustody = supplyCmd.Flags().StringArray("custody", nil, "Custody address excluded from the circulating supply, added to supply.custody in config, repeatable")
+	supplyJSON = supplyCmd.Flags().Bool("json", false, "Print the report as JSON")
+	_ = supplyCmd.MarkFlagRequired("uuid")
+}
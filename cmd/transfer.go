@@ -4,7 +4,6 @@ import (
 	"context"
 	"fmt"
 	"github.com/nervosnetwork/ckb-sdk-go/address"
-	"github.com/nervosnetwork/ckb-sdk-go/crypto/secp256k1"
 	"github.com/nervosnetwork/ckb-sdk-go/indexer"
 	"github.com/nervosnetwork/ckb-sdk-go/rpc"
 	"github.com/nervosnetwork/ckb-sdk-go/transaction"
@@ -13,32 +12,51 @@ import (
 	"github.com/ququzone/ckb-udt-cli/config"
 	"github.com/spf13/cobra"
 	"math/big"
+	"strings"
+	"time"
 )
 
 var (
-	transferConf   *string
-	transferKey    *string
-	transferAmount *string
-	transferTo     *string
-	transferUUID   *string
+	transferConf         *string
+	transferKey          *KeyOptions
+	transferAmount       *string
+	transferTo           *string
+	transferUUID         *string
+	transferStandard     *string
+	transferAdjustMin    *bool
+	transferChangeTo     *string
+	transferSelection    *string
+	transferOutPoints    *OutPointFlags
+	transferChainPending *bool
+	transferSend         *SendOptions
+	transferRetries      *int
+)
+
+const (
+	// transferRetryWait bounds how long a retry waits for the indexer to show the recipient's new cell.
+	transferRetryWait = 2 * time.Minute
+	transferRetryPoll = 3 * time.Second
 )
 
 var transferCmd = &cobra.Command{
 	Use:   "transfer",
-	Short: "Transfer sUDT token",
-	Long:  `Transfer sUDT from secp256k1 lock cell.`,
+	Short: "Transfer sUDT or xUDT token",
+	Long:  `Transfer sUDT or xUDT from anyone can pay and secp256k1 lock cells.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		c, err := config.Init(*transferConf)
 		if err != nil {
 			Fatalf("load config error: %v", err)
 		}
+		if err = c.SelectStandard(*transferStandard); err != nil {
+			Fatalf("token standard error: %v", err)
+		}
 
-		client, err := rpc.DialWithIndexer(c.RPC, c.CkbIndexer)
+		client, err := Dial(c)
 		if err != nil {
 			Fatalf("create rpc client error: %v", err)
 		}
 
-		key, err := secp256k1.HexToKey(*transferKey)
+		key, err := transferKey.Load()
 		if err != nil {
 			Fatalf("import private key error: %v", err)
 		}
@@ -49,83 +67,143 @@ var transferCmd = &cobra.Command{
 			Fatalf("transfer amount error: %s", *transferAmount)
 		}
 
-		uuid := types.HexToHash(*transferUUID).Bytes()
+		uuid := ParseUUID(*transferUUID)
+
+		selector, err := NewCoinSelector(*transferSelection)
+		if err != nil {
+			Fatalf("coin selection error: %v", err)
+		}
+		filter, err := transferOutPoints.Filter()
+		if err != nil {
+			Fatalf("outpoint filter error: %v", err)
+		}
+		store, err := OpenReservations(client, c, filter)
+		if err != nil {
+			Fatalf("open reservation store error: %v", err)
+		}
+		if *transferChainPending {
+			store.OfferPending(filter)
+		}
 
-		capacity := uint64(28400000000)
 		fee := uint64(953)
 		recipientAddr, err := address.Parse(*transferTo)
 		if err != nil {
 			Fatalf("parse to address error: %v", err)
 		}
 
-		var recipientCell *indexer.LiveCell
-		if recipientAddr.Script.CodeHash.String() == c.ACP.Script.CodeHash {
-			searchKey := &indexer.SearchKey{
-				Script:     recipientAddr.Script,
-				ScriptType: "lock",
-			}
-			cells, err := CollectUDT(client, c, searchKey, "asc", 1000, "", uuid, big.NewInt(0))
-			if err != nil {
-				Fatalf("collect cell error: %v", err)
-			}
-			if len(cells.LiveCells) == 0 {
-				Fatalf("can't find anyone can pay cell for %s", *transferTo)
-			}
-			recipientCell = cells.LiveCells[0]
-		}
-
 		scripts, err := utils.NewSystemScripts(client)
 		if err != nil {
 			Fatalf("load system script error: %v", err)
 		}
 
-		fromAcp := true
-		fromSecp256k1Script, err := key.Script(scripts)
+		secpScript, err := key.Script(scripts)
 		if err != nil {
 			Fatalf("load system script error: %v", err)
 		}
-		fromScript := &types.Script{
+		acpScript := &types.Script{
 			CodeHash: types.HexToHash(c.ACP.Script.CodeHash),
 			HashType: types.ScriptHashType(c.ACP.Script.HashType),
-			Args:     fromSecp256k1Script.Args,
-		}
-		searchKey := &indexer.SearchKey{
-			Script:     fromScript,
-			ScriptType: "lock",
-		}
-		cells, err := CollectUDT(client, c, searchKey, "asc", 1000, "", uuid, amount)
-		if err != nil {
-			Fatalf("collect cell error: %v", err)
+			Args:     secpScript.Args,
 		}
 
-		if cells.Options["total"].(*big.Int).Cmp(amount) < 0 {
-			fromScript = fromSecp256k1Script
+		build := func(retry bool) (*types.Transaction, *indexer.LiveCell) {
+			var recipientCell *indexer.LiveCell
+			if recipientAddr.Script.CodeHash.String() == c.ACP.Script.CodeHash {
+				searchKey := &indexer.SearchKey{
+					Script:     recipientAddr.Script,
+					ScriptType: "lock",
+				}
+				// skip recipient cells already spent by pending transactions, chaining on their outputs if offered
+				recipientFilter := filter.ExcludeOnly()
+				// after a conflict the spent cell is excluded, wait for the indexer to show its replacement
+				deadline := time.Now().Add(transferRetryWait)
+				for {
+					cells, err := CollectUDT(client, c, searchKey, "asc", 1000, "", uuid, big.NewInt(0), recipientFilter)
+					if err != nil {
+						Fatalf("collect cell error: %v", err)
+					}
+					candidates := append(cells.LiveCells, recipientFilter.ExtraCells(searchKey, UDTTypeScript(c, uuid))...)
+					if len(candidates) > 0 {
+						recipientCell = candidates[0]
+						break
+					}
+					if !retry || time.Now().After(deadline) {
+						Fatalf("can't find anyone can pay cell for %s", *transferTo)
+					}
+					if !sleepContext(rootContext, transferRetryPoll) {
+						Fatalf("wait recipient anyone can pay cell error: %v", rootContext.Err())
+					}
+				}
+			}
+
+			// anyone can pay lock rejects payments below its minimum
+			extraCapacity := uint64(0)
+			if recipientCell != nil {
+				min, err := ParseACPArgs(recipientCell.Output.Lock.Args)
+				if err != nil {
+					Fatalf("parse anyone can pay args error: %v", err)
+				}
+				if (min.CKB != nil || min.UDT != nil) && (min.UDT == nil || amount.Cmp(min.UDT) < 0) {
+					if !*transferAdjustMin {
+						Fatalf("transfer amount %s is below the minimum payment of %s, use --adjust-min to pay the minimum", amount.String(), describeACPMinimum(min))
+					}
+					if min.CKB != nil {
+						if !min.CKB.IsUint64() {
+							Fatalf("anyone can pay CKB minimum %s overflows", min.CKB.String())
+						}
+						extraCapacity = min.CKB.Uint64()
+						fmt.Printf("paying minimum %d shannons to anyone can pay cell\n", extraCapacity)
+					} else {
+						amount = min.UDT
+						fmt.Printf("raising transfer amount to minimum %s\n", amount.String())
+					}
+				}
+			}
+			// collect from anyone can pay cells first and make up the rest from secp256k1 cells
 			searchKey := &indexer.SearchKey{
-				Script:     fromScript,
+				Script:     acpScript,
 				ScriptType: "lock",
 			}
-			cells, err = CollectUDT(client, c, searchKey, "asc", 1000, "", uuid, amount)
+			acpCells, err := SelectUDT(client, c, searchKey, uuid, amount, selector, filter)
 			if err != nil {
 				Fatalf("collect cell error: %v", err)
 			}
-			if cells.Options["total"].(*big.Int).Cmp(amount) < 0 {
-				Fatalf("insufficient UDT balance")
+			total := big.NewInt(0).Set(acpCells.Options["total"].(*big.Int))
+			var secpCells *utils.LiveCellCollectResult
+			if total.Cmp(amount) < 0 || filter.HasUnusedPins() {
+				searchKey = &indexer.SearchKey{
+					Script:     secpScript,
+					ScriptType: "lock",
+				}
+				secpCells, err = SelectUDT(client, c, searchKey, uuid, big.NewInt(0).Sub(amount, total), selector, filter)
+				if err != nil {
+					Fatalf("collect cell error: %v", err)
+				}
+				total.Add(total, secpCells.Options["total"].(*big.Int))
+				if total.Cmp(amount) < 0 {
+					Fatalf("insufficient UDT balance: %s < %s", total.String(), amount.String())
+				}
+			}
+			sourceCells := acpCells.LiveCells
+			inputCapacity := acpCells.Capacity
+			if secpCells != nil {
+				sourceCells = append(sourceCells, secpCells.LiveCells...)
+				inputCapacity += secpCells.Capacity
 			}
-			fromAcp = false
-		}
 
-		tx := transaction.NewSecp256k1SingleSigTx(scripts)
-		for _, dep := range c.UDT.Deps {
-			tx.CellDeps = append(tx.CellDeps, &types.CellDep{
-				OutPoint: &types.OutPoint{
-					TxHash: types.HexToHash(dep.TxHash),
-					Index:  dep.Index,
-				},
-				DepType: types.DepType(dep.DepType),
-			})
-		}
-		if fromAcp || recipientCell != nil {
-			for _, dep := range c.ACP.Deps {
+			changeTo := *transferChangeTo
+			if changeTo == "" {
+				changeTo = "secp"
+				if len(acpCells.LiveCells) > 0 {
+					changeTo = "acp"
+				}
+			}
+			if changeTo != "acp" && changeTo != "secp" {
+				Fatalf("invalid change target: %s", changeTo)
+			}
+
+			tx := transaction.NewSecp256k1SingleSigTx(scripts)
+			for _, dep := range c.UDT.Deps {
 				tx.CellDeps = append(tx.CellDeps, &types.CellDep{
 					OutPoint: &types.OutPoint{
 						TxHash: types.HexToHash(dep.TxHash),
@@ -134,169 +212,192 @@ var transferCmd = &cobra.Command{
 					DepType: types.DepType(dep.DepType),
 				})
 			}
-		}
-
-		var feeCells *utils.LiveCellCollectResult
-		searchKey = &indexer.SearchKey{
-			Script:     fromScript,
-			ScriptType: "lock",
-		}
-		if cells.Capacity < capacity+fee {
-			cellCollector := utils.NewLiveCellCollector(client, searchKey, "asc", 1000, "", utils.NewCapacityLiveCellProcessor(capacity+fee-cells.Capacity))
-			cellCollector.EmptyData = true
-			feeCells, err = cellCollector.Collect()
-			if err != nil {
-				Fatalf("collect cell error: %v", err)
+			if len(acpCells.LiveCells) > 0 || recipientCell != nil {
+				for _, dep := range c.ACP.Deps {
+					tx.CellDeps = append(tx.CellDeps, &types.CellDep{
+						OutPoint: &types.OutPoint{
+							TxHash: types.HexToHash(dep.TxHash),
+							Index:  dep.Index,
+						},
+						DepType: types.DepType(dep.DepType),
+					})
+				}
 			}
 
-			if feeCells.Capacity < capacity+fee-cells.Capacity {
-				Fatalf("insufficient capacity: %d < %d", cells.Capacity+feeCells.Capacity, capacity+fee)
+			if recipientCell != nil {
+				input := &types.CellInput{
+					Since: 0,
+					PreviousOutput: &types.OutPoint{
+						TxHash: recipientCell.OutPoint.TxHash,
+						Index:  recipientCell.OutPoint.Index,
+					},
+				}
+				tx.Inputs = append(tx.Inputs, input)
+				tx.Witnesses = append(tx.Witnesses, []byte{})
+				tx.Outputs = append(tx.Outputs, &types.CellOutput{
+					Capacity: recipientCell.Output.Capacity + extraCapacity,
+					Lock:     recipientCell.Output.Lock,
+					Type:     recipientCell.Output.Type,
+				})
+				origin, err := ParseUDTAmount(recipientCell.OutputData)
+				if err != nil {
+					Fatalf("parse anyone can pay amount error: %v", err)
+				}
+				b, err := UpdateSudtAmount(recipientCell.OutputData, big.NewInt(0).Add(origin, amount))
+				if err != nil {
+					Fatalf("update anyone can pay amount error: %v", err)
+				}
+				tx.OutputsData = append(tx.OutputsData, b)
+			} else {
+				tx.Outputs = append(tx.Outputs, &types.CellOutput{
+					Capacity: UDTCellCapacity(recipientAddr.Script, UDTTypeScript(c, uuid)),
+					Lock:     recipientAddr.Script,
+					Type:     UDTTypeScript(c, uuid),
+				})
+				tx.OutputsData = append(tx.OutputsData, utils.GenerateSudtAmount(amount))
 			}
-		}
 
-		if recipientCell != nil {
-			capacity -= 14200000000
-			input := &types.CellInput{
-				Since: 0,
-				PreviousOutput: &types.OutPoint{
-					TxHash: recipientCell.OutPoint.TxHash,
-					Index:  recipientCell.OutPoint.Index,
-				},
-			}
-			tx.Inputs = append(tx.Inputs, input)
-			tx.Witnesses = append(tx.Witnesses, []byte{})
-			tx.Outputs = append(tx.Outputs, &types.CellOutput{
-				Capacity: recipientCell.Output.Capacity,
-				Lock:     recipientCell.Output.Lock,
-				Type:     recipientCell.Output.Type,
-			})
-			originTx, err := client.GetTransaction(context.Background(), recipientCell.OutPoint.TxHash)
-			if err != nil {
-				Fatalf("query anyone can pay transaction error: %v", err)
+			// token change, a consumed anyone can pay cell is always recreated so the address keeps receiving
+			tokenChange := big.NewInt(0).Sub(total, amount)
+			if len(acpCells.LiveCells) > 0 || (changeTo == "acp" && tokenChange.Sign() > 0) {
+				output := &types.CellOutput{
+					Capacity: UDTCellCapacity(acpScript, UDTTypeScript(c, uuid)),
+					Lock:     acpScript,
+					Type:     UDTTypeScript(c, uuid),
+				}
+				data := utils.GenerateSudtAmount(big.NewInt(0))
+				if len(acpCells.LiveCells) > 0 {
+					origin := acpCells.LiveCells[0]
+					output.Lock = origin.Output.Lock
+					if origin.Output.Capacity > output.Capacity {
+						output.Capacity = origin.Output.Capacity
+					}
+					data = origin.OutputData
+				}
+				acpChange := big.NewInt(0)
+				if changeTo == "acp" {
+					acpChange = tokenChange
+				}
+				b, err := UpdateSudtAmount(data, acpChange)
+				if err != nil {
+					Fatalf("update anyone can pay amount error: %v", err)
+				}
+				tx.Outputs = append(tx.Outputs, output)
+				tx.OutputsData = append(tx.OutputsData, b)
 			}
-			b := originTx.Transaction.OutputsData[recipientCell.OutPoint.Index]
-			for i := 0; i < len(b)/2; i++ {
-				b[i], b[len(b)-i-1] = b[len(b)-i-1], b[i]
+			if changeTo == "secp" && tokenChange.Sign() > 0 {
+				tx.Outputs = append(tx.Outputs, &types.CellOutput{
+					Capacity: UDTCellCapacity(secpScript, UDTTypeScript(c, uuid)),
+					Lock:     secpScript,
+					Type:     UDTTypeScript(c, uuid),
+				})
+				tx.OutputsData = append(tx.OutputsData, utils.GenerateSudtAmount(tokenChange))
 			}
-			origin := big.NewInt(0).SetBytes(b)
 
-			b = big.NewInt(0).Add(origin, amount).Bytes()
-			for i := 0; i < len(b)/2; i++ {
-				b[i], b[len(b)-i-1] = b[len(b)-i-1], b[i]
+			required := fee
+			for _, output := range tx.Outputs {
+				required += output.Capacity
 			}
-			if len(b) < 16 {
-				for i := len(b); i < 16; i++ {
-					b = append(b, 0)
-				}
+			if recipientCell != nil {
+				required -= recipientCell.Output.Capacity
 			}
-			tx.OutputsData = append(tx.OutputsData, b)
-		} else {
-			tx.Outputs = append(tx.Outputs, &types.CellOutput{
-				Capacity: 14200000000,
-				Lock:     recipientAddr.Script,
-				Type: &types.Script{
-					CodeHash: types.HexToHash(c.UDT.Script.CodeHash),
-					HashType: types.ScriptHashType(c.UDT.Script.HashType),
-					Args:     uuid,
-				},
-			})
 
-			b := amount.Bytes()
-			for i := 0; i < len(b)/2; i++ {
-				b[i], b[len(b)-i-1] = b[len(b)-i-1], b[i]
-			}
-			if len(b) < 16 {
-				for i := len(b); i < 16; i++ {
-					b = append(b, 0)
+			var feeCells *utils.LiveCellCollectResult
+			if inputCapacity < required+6100000000 || filter.HasUnusedPins() {
+				searchKey = &indexer.SearchKey{
+					Script:     secpScript,
+					ScriptType: "lock",
 				}
-			}
-			tx.OutputsData = append(tx.OutputsData, b)
-		}
-
-		tx.Outputs = append(tx.Outputs, &types.CellOutput{
-			Capacity: 14200000000,
-			Lock:     fromScript,
-			Type: &types.Script{
-				CodeHash: types.HexToHash(c.UDT.Script.CodeHash),
-				HashType: types.ScriptHashType(c.UDT.Script.HashType),
-				Args:     uuid,
-			},
-		})
-		if cells.Options["total"].(*big.Int).Cmp(amount) == 0 {
-			tx.OutputsData = append(tx.OutputsData, []byte{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0})
-		} else {
-			b := big.NewInt(0).Sub(cells.Options["total"].(*big.Int), amount).Bytes()
-			for i := 0; i < len(b)/2; i++ {
-				b[i], b[len(b)-i-1] = b[len(b)-i-1], b[i]
-			}
-			if len(b) < 16 {
-				for i := len(b); i < 16; i++ {
-					b = append(b, 0)
+				feeCells, err = SelectCapacity(client, searchKey, required+6100000000-inputCapacity, selector, filter)
+				if err != nil {
+					Fatalf("collect cell error: %v", err)
+				}
+				inputCapacity += feeCells.Capacity
+				sourceCells = append(sourceCells, feeCells.LiveCells...)
+				if inputCapacity < required {
+					Fatalf("insufficient capacity: %d < %d", inputCapacity, required)
 				}
 			}
-			tx.OutputsData = append(tx.OutputsData, b)
-		}
 
-		if cells.Capacity-capacity-fee >= 6100000000 || (feeCells != nil && cells.Capacity-capacity-fee+feeCells.Capacity >= 6100000000) {
-			change := cells.Capacity - fee - 14200000000
-			if feeCells != nil {
-				change += feeCells.Capacity
-			}
-			if recipientCell == nil {
-				change -= 14200000000
+			if missing := filter.Missing(); len(missing) > 0 {
+				Fatalf("pinned outpoints are not spendable: %s", strings.Join(missing, ", "))
 			}
-			if change >= 6100000000 {
+
+			switch leftover := inputCapacity - required; {
+			case leftover >= 6100000000:
 				tx.Outputs = append(tx.Outputs, &types.CellOutput{
-					Capacity: change,
-					Lock:     fromScript,
+					Capacity: leftover,
+					Lock:     secpScript,
 				})
-
 				tx.OutputsData = append(tx.OutputsData, []byte{})
+			case leftover == 0:
+				// balanced, no change cell needed
+			case len(tx.Outputs) > 1:
+				// too small for a change cell, keep it in the last token change cell
+				tx.Outputs[len(tx.Outputs)-1].Capacity += leftover
+			default:
+				Fatalf("insufficient capacity for change cell: %d", leftover)
 			}
-		} else {
-			change := cells.Capacity - fee
-			if feeCells != nil {
-				change += feeCells.Capacity
+
+			var groups [][]int
+			var witnesses []*types.WitnessArgs
+			for _, cells := range GroupLiveCellsByLock(sourceCells) {
+				group, witnessArgs, err := AddLiveCellInputs(tx, cells)
+				if err != nil {
+					Fatalf("add inputs to transaction error: %v", err)
+				}
+				groups = append(groups, group)
+				witnesses = append(witnesses, witnessArgs)
 			}
-			if recipientCell == nil {
-				change -= 14200000000
+
+			if err = ValidateTransaction(client, c, tx); err != nil {
+				Fatalf("validate transaction error: %v", err)
 			}
-			tx.Outputs[1].Capacity = change
-		}
 
-		var inputs []*types.CellInput
-		for _, cell := range cells.LiveCells {
-			inputs = append(inputs, &types.CellInput{
-				Since:          0,
-				PreviousOutput: cell.OutPoint,
-			})
-		}
-		if feeCells != nil {
-			for _, cell := range feeCells.LiveCells {
-				inputs = append(inputs, &types.CellInput{
-					Since:          0,
-					PreviousOutput: cell.OutPoint,
-				})
+			for i, group := range groups {
+				err = transaction.SingleSignTransaction(tx, group, witnesses[i], key)
+				if err != nil {
+					Fatalf("sign transaction error: %v", err)
+				}
 			}
-		}
 
-		group, witnessArgs, err := transaction.AddInputsForTransaction(tx, inputs)
-		if err != nil {
-			Fatalf("add inputs to transaction error: %v", err)
+			return tx, recipientCell
 		}
 
-		err = transaction.SingleSignTransaction(tx, group, witnessArgs, key)
-		if err != nil {
-			Fatalf("sign transaction error: %v", err)
-		}
+		var hash *types.Hash
+		for attempt := 0; ; attempt++ {
+			tx, recipientCell := build(attempt > 0)
+			transferSend.VerifyTransaction(client, c, tx)
+			err = store.Reserve(tx)
+			if err != nil {
+				Fatalf("reserve inputs error: %v", err)
+			}
+			store.Unlock()
 
-		hash, err := client.SendTransaction(context.Background(), tx)
-		if err != nil {
-			fmt.Println(rpc.TransactionString(tx))
-			Fatalf("send transaction error: %v", err)
+			hash, err = client.SendTransaction(context.Background(), tx)
+			if err == nil {
+				if err = store.Confirm(tx, *hash); err != nil {
+					fmt.Printf("record reservation error: %v\n", err)
+				}
+				break
+			}
+			_ = store.Release(tx)
+			if recipientCell == nil || !SpentConcurrently(err, recipientCell.OutPoint) {
+				fmt.Println(rpc.TransactionString(tx))
+				Fatalf("send transaction error: %s", ExplainScriptError(client, c, tx, err))
+			}
+			if attempt >= *transferRetries {
+				Fatalf("recipient anyone can pay cell %s was spent concurrently, giving up after %d retries: %v", OutPointString(recipientCell.OutPoint), attempt, err)
+			}
+			fmt.Printf("recipient anyone can pay cell %s was spent concurrently, rebuilding (retry %d/%d)\n", OutPointString(recipientCell.OutPoint), attempt+1, *transferRetries)
+			filter.AddExcludes([]string{OutPointString(recipientCell.OutPoint)})
+			if err = store.LockExcluding(client, filter); err != nil {
+				Fatalf("open reservation store error: %v", err)
+			}
 		}
 
 		fmt.Printf("transfer transaction hash: %s\n", hash.String())
+		transferSend.WaitCommitted(client, hash)
 	},
 }
 
@@ -304,11 +405,18 @@ func init() {
 	rootCmd.AddCommand(transferCmd)
 
 	transferConf = transferCmd.Flags().StringP("config", "c", "config.yaml", "Config file")
-	transferKey = transferCmd.Flags().StringP("key", "k", "", "From private key")
+	transferKey = AddKeyFlags(transferCmd.Flags(), "From private key")
 	transferUUID = transferCmd.Flags().StringP("uuid", "u", "", "UDT uuid")
+	transferStandard = transferCmd.Flags().String("standard", StandardSUDT, "Token standard: sudt or xudt")
 	transferAmount = transferCmd.Flags().StringP("amount", "a", "", "Transfer amount")
 	transferTo = transferCmd.Flags().StringP("to", "t", "", "Transfer recipient address")
-	_ = transferCmd.MarkFlagRequired("key")
+	transferAdjustMin = transferCmd.Flags().Bool("adjust-min", false, "Pay the recipient anyone can pay cell minimum instead of failing when the amount is below it")
+	transferChangeTo = transferCmd.Flags().String("change-to", "", "Where to put token change, acp or secp (default acp when an anyone can pay cell is spent)")
+	transferSelection = transferCmd.Flags().String("coin-selection", OldestFirst, "Coin selection strategy: largest-first, smallest-first, fewest-inputs or oldest-first")
+	transferOutPoints = AddOutPointFlags(transferCmd)
+	transferChainPending = transferCmd.Flags().Bool("chain-pending", false, "Allow spending outputs of transactions this tool sent that are not committed yet")
+	transferRetries = transferCmd.Flags().Int("retries", 3, "Times to rebuild and resend when the recipient anyone can pay cell is spent concurrently")
+	transferSend = AddSendFlags(transferCmd)
 	_ = transferCmd.MarkFlagRequired("amount")
 	_ = transferCmd.MarkFlagRequired("uuid")
 	_ = transferCmd.MarkFlagRequired("to")
@@ -0,0 +1,186 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/nervosnetwork/ckb-sdk-go/rpc"
+	"github.com/nervosnetwork/ckb-sdk-go/types"
+	"github.com/ququzone/ckb-udt-cli/config"
+	"github.com/spf13/cobra"
+)
+
+const (
+	txStatusRejected types.TransactionStatus = "rejected"
+	txStatusUnknown  types.TransactionStatus = "unknown"
+
+	waitPollInterval = 3 * time.Second
+)
+
+var (
+	txStatusConf          *string
+	txStatusWait          *bool
+	txStatusConfirmations *uint64
+	txStatusTimeout       *time.Duration
+)
+
+// SendOptions holds the flags shared by every command that sends a transaction.
+type SendOptions struct {
+	Wait          *bool
+	Confirmations *uint64
+	Timeout       *time.Duration
+	Verify        *bool
+}
+
+func AddSendFlags(cmd *cobra.Command) *SendOptions {
+	return &SendOptions{
+		Wait:          cmd.Flags().Bool("wait", false, "Wait until the transaction is committed"),
+		Confirmations: cmd.Flags().Uint64("confirmations", 1, "Number of blocks the transaction must be committed under when waiting"),
+		Timeout:       cmd.Flags().Duration("wait-timeout", 10*time.Minute, "Maximum time to wait for the transaction"),
+		Verify:        cmd.Flags().Bool("verify", false, "Dry run the signed transaction through the node before sending"),
+	}
+}
+
+// WaitCommitted blocks until hash reaches the requested confirmations when --wait is set.
+func (o *SendOptions) WaitCommitted(client rpc.Client, hash *types.Hash) {
+	if !*o.Wait {
+		return
+	}
+	if err := WaitTransaction(client, *hash, *o.Confirmations, *o.Timeout); err != nil {
+		Fatalf("wait transaction error: %v", err)
+	}
+}
+
+// VerifyTransaction dry runs tx through the node when --verify is set, reporting the cycles or the failing script.
+func (o *SendOptions) VerifyTransaction(client rpc.Client, c *config.Config, tx *types.Transaction) {
+	if !*o.Verify {
+		return
+	}
+	result, err := client.DryRunTransaction(context.Background(), tx)
+	if err != nil {
+		Fatalf("dry run error: %s", ExplainScriptError(client, c, tx, err))
+	}
+	fmt.Printf("dry run passed, cycles: %d\n", result.Cycles)
+}
+
+type TransactionState struct {
+	Status        types.TransactionStatus
+	BlockNumber   uint64
+	Confirmations uint64
+}
+
+func QueryTransactionState(client rpc.Client, hash types.Hash) (*TransactionState, error) {
+	tx, err := client.GetTransaction(context.Background(), hash)
+	if err != nil {
+		return nil, err
+	}
+	state := &TransactionState{Status: tx.TxStatus.Status}
+	if state.Status == "" {
+		state.Status = txStatusUnknown
+	}
+	if state.Status != types.TransactionStatusCommitted || tx.TxStatus.BlockHash == nil {
+		return state, nil
+	}
+
+	header, err := client.GetHeader(context.Background(), *tx.TxStatus.BlockHash)
+	if err != nil {
+		return nil, err
+	}
+	tip, err := client.GetTipBlockNumber(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	state.BlockNumber = header.Number
+	if tip >= header.Number {
+		state.Confirmations = tip - header.Number + 1
+	}
+	return state, nil
+}
+
+// WaitTransaction polls the node until hash is committed at least confirmations blocks deep.
+func WaitTransaction(client rpc.Client, hash types.Hash, confirmations uint64, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	var last types.TransactionStatus
+	for {
+		state, err := QueryTransactionState(client, hash)
+		if err != nil {
+			return err
+		}
+		if state.Status != last {
+			fmt.Printf("transaction %s is %s\n", hash.String(), state.Status)
+			last = state.Status
+		}
+		switch state.Status {
+		case txStatusRejected:
+			return errors.New("transaction rejected by node")
+		case txStatusUnknown:
+			return errors.New("transaction not found in pool or chain")
+		case types.TransactionStatusCommitted:
+			if state.Confirmations >= confirmations {
+				fmt.Printf("transaction %s committed at block %d with %d confirmations\n", hash.String(), state.BlockNumber, state.Confirmations)
+				return nil
+			}
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("timeout after %s, last status: %s", timeout, state.Status)
+		}
+		if !sleepContext(rootContext, waitPollInterval) {
+			return rootContext.Err()
+		}
+	}
+}
+
+var txCmd = &cobra.Command{
+	Use:   "tx",
+	Short: "Transaction utilities",
+	Long:  `Transaction utilities.`,
+}
+
+var txStatusCmd = &cobra.Command{
+	Use:   "status HASH",
+	Short: "Query transaction status",
+	Long:  `Query transaction status, optionally waiting until it is committed.`,
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		c, err := config.Init(*txStatusConf)
+		if err != nil {
+			Fatalf("load config error: %v", err)
+		}
+
+		client, err := Dial(c)
+		if err != nil {
+			Fatalf("create rpc client error: %v", err)
+		}
+
+		hash := types.HexToHash(args[0])
+		if *txStatusWait {
+			err = WaitTransaction(client, hash, *txStatusConfirmations, *txStatusTimeout)
+			if err != nil {
+				Fatalf("wait transaction error: %v", err)
+			}
+			return
+		}
+
+		state, err := QueryTransactionState(client, hash)
+		if err != nil {
+			Fatalf("query transaction error: %v", err)
+		}
+		if state.Status == types.TransactionStatusCommitted {
+			fmt.Printf("transaction %s is committed at block %d with %d confirmations\n", hash.String(), state.BlockNumber, state.Confirmations)
+		} else {
+			fmt.Printf("transaction %s is %s\n", hash.String(), state.Status)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(txCmd)
+	txCmd.AddCommand(txStatusCmd)
+
+	txStatusConf = txStatusCmd.Flags().StringP("config", "c", "config.yaml", "Config file")
+	txStatusWait = txStatusCmd.Flags().Bool("wait", false, "Wait until the transaction is committed")
+	txStatusConfirmations = txStatusCmd.Flags().Uint64("confirmations", 1, "Number of blocks the transaction must be committed under when waiting")
+	txStatusTimeout = txStatusCmd.Flags().Duration("wait-timeout", 10*time.Minute, "Maximum time to wait for the transaction")
+}
@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/nervosnetwork/ckb-sdk-go/types"
+)
+
+// deadOutPointPattern matches inputs the node failed to resolve, e.g. Dead(OutPoint(0x<tx hash><index LE u32>)).
+var deadOutPointPattern = regexp.MustCompile(`(?:Dead|Unknown)\(OutPoint\(0x([0-9a-fA-F]{72})\)\)`)
+
+// DeadOutPoints returns the inputs a send error reports as already spent or unknown.
+func DeadOutPoints(err error) []*types.OutPoint {
+	if err == nil {
+		return nil
+	}
+	var result []*types.OutPoint
+	for _, match := range deadOutPointPattern.FindAllStringSubmatch(err.Error(), -1) {
+		b, e := hex.DecodeString(match[1])
+		if e != nil {
+			continue
+		}
+		result = append(result, &types.OutPoint{
+			TxHash: types.BytesToHash(b[:32]),
+			Index:  uint(binary.LittleEndian.Uint32(b[32:])),
+		})
+	}
+	return result
+}
+
+// SpentConcurrently reports whether a send error is caused by outPoint being consumed by another transaction.
+// A conflict error naming no outpoint is not attributed to outPoint, so the caller reports it as is.
+func SpentConcurrently(err error, outPoint *types.OutPoint) bool {
+	for _, o := range DeadOutPoints(err) {
+		if o.TxHash == outPoint.TxHash && o.Index == outPoint.Index {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	scriptErrorSourcePattern = regexp.MustCompile(`(?i)\b(inputs?|outputs?)\[(\d+)\]\.(lock|type)\b`)
+	scriptErrorCodePattern   = regexp.MustCompile(`(?:ValidationFailure\(|error code )(-?\d+)`)
+)
+
+// ScriptError is a script failure reported by the node, located at the first input or output of the failing script group.
+type ScriptError struct {
+	Input  bool
+	Index  int
+	Script string
+	Code   int
+}
+
+func ParseScriptError(err error) (*ScriptError, bool) {
+	if err == nil {
+		return nil, false
+	}
+	msg := err.Error()
+	source := scriptErrorSourcePattern.FindStringSubmatch(msg)
+	code := scriptErrorCodePattern.FindStringSubmatch(msg)
+	if source == nil || code == nil {
+		return nil, false
+	}
+	e := &ScriptError{
+		Input:  strings.HasPrefix(strings.ToLower(source[1]), "input"),
+		Script: strings.ToLower(source[3]),
+	}
+	e.Index, _ = strconv.Atoi(source[2])
+	e.Code, _ = strconv.Atoi(code[1])
+	return e, true
+}
+
+// Describe names the failing script and the cell of tx it belongs to.
+func (e *ScriptError) Describe(tx *types.Transaction) string {
+	if e.Input {
+		if e.Index < len(tx.Inputs) {
+			return fmt.Sprintf("input %d %s script (%s)", e.Index, e.Script, OutPointString(tx.Inputs[e.Index].PreviousOutput))
+		}
+		return fmt.Sprintf("input %d %s script", e.Index, e.Script)
+	}
+	if e.Index < len(tx.Outputs) {
+		return fmt.Sprintf("output %d %s script (%s)", e.Index, e.Script, lockString(tx.Outputs[e.Index].Lock))
+	}
+	return fmt.Sprintf("output %d %s script", e.Index, e.Script)
+}
@@ -1,6 +1,8 @@
 package cmd
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"github.com/nervosnetwork/ckb-sdk-go/indexer"
 	"github.com/ququzone/ckb-udt-cli/config"
@@ -8,13 +10,27 @@ import (
 	"os"
 
 	"github.com/nervosnetwork/ckb-sdk-go/rpc"
+	"github.com/nervosnetwork/ckb-sdk-go/transaction"
 	"github.com/nervosnetwork/ckb-sdk-go/types"
 	"github.com/nervosnetwork/ckb-sdk-go/utils"
 )
 
+var exitHooks []func()
+
+// exit terminates the process after Fatalf, tests replace it to keep the test binary running.
+var exit = os.Exit
+
+// AtExit registers f to run when Fatalf terminates the process.
+func AtExit(f func()) {
+	exitHooks = append(exitHooks, f)
+}
+
 func Fatalf(format string, v ...interface{}) {
 	fmt.Printf(format+"\n", v...)
-	os.Exit(1)
+	for i := len(exitHooks) - 1; i >= 0; i-- {
+		exitHooks[i]()
+	}
+	exit(1)
 }
 
 type UDTCellProcessor struct {
@@ -32,7 +48,7 @@ func NewUDTCellProcessor(client rpc.Client, max *big.Int) *UDTCellProcessor {
 func (p *UDTCellProcessor) Process(liveCell *indexer.LiveCell, result *utils.LiveCellCollectResult) (bool, error) {
 	result.Capacity = result.Capacity + liveCell.Output.Capacity
 	result.LiveCells = append(result.LiveCells, liveCell)
-	amount, err := utils.ParseSudtAmount(liveCell.OutputData)
+	amount, err := ParseUDTAmount(liveCell.OutputData)
 	if err != nil {
 		return false, err
 	}
@@ -49,14 +65,22 @@ func (p *UDTCellProcessor) Process(liveCell *indexer.LiveCell, result *utils.Liv
 	return false, nil
 }
 
-func CollectUDT(client rpc.Client, c *config.Config, searchKey *indexer.SearchKey, searchOrder indexer.SearchOrder, limit uint64, afterCursor string, uuid []byte, max *big.Int) (*utils.LiveCellCollectResult, error) {
-	cellCollector := utils.NewLiveCellCollector(client, searchKey, searchOrder, limit, afterCursor, NewUDTCellProcessor(client, max))
-	cellCollector.EmptyData = false
-	cellCollector.TypeScript = &types.Script{
+func UDTTypeScript(c *config.Config, uuid []byte) *types.Script {
+	return &types.Script{
 		CodeHash: types.HexToHash(c.UDT.Script.CodeHash),
 		HashType: types.ScriptHashType(c.UDT.Script.HashType),
 		Args:     uuid,
 	}
+}
+
+func CollectUDT(client rpc.Client, c *config.Config, searchKey *indexer.SearchKey, searchOrder indexer.SearchOrder, limit uint64, afterCursor string, uuid []byte, max *big.Int, filter *OutPointFilter) (*utils.LiveCellCollectResult, error) {
+	var processor utils.LiveCellProcessor = NewUDTCellProcessor(client, max)
+	if filter != nil {
+		processor = &FilteredCellProcessor{Filter: filter, Processor: processor}
+	}
+	cellCollector := utils.NewLiveCellCollector(client, searchKey, searchOrder, limit, afterCursor, processor)
+	cellCollector.EmptyData = false
+	cellCollector.TypeScript = UDTTypeScript(c, uuid)
 	cells, err := cellCollector.Collect()
 	if err != nil {
 		return nil, err
@@ -69,3 +93,88 @@ func CollectUDT(client rpc.Client, c *config.Config, searchKey *indexer.SearchKe
 	}
 	return cells, nil
 }
+
+// AddLiveCellInputs appends cells to tx as one lock group, returning the group for signing.
+func AddLiveCellInputs(tx *types.Transaction, cells []*indexer.LiveCell) ([]int, *types.WitnessArgs, error) {
+	var inputs []*types.CellInput
+	for _, cell := range cells {
+		inputs = append(inputs, &types.CellInput{
+			Since:          0,
+			PreviousOutput: cell.OutPoint,
+		})
+	}
+	return transaction.AddInputsForTransaction(tx, inputs)
+}
+
+// UDTCellCapacity returns the capacity of a new token cell with lock and typeScript, the usual 142 CKB
+// or more when long lock or xUDT args occupy more.
+func UDTCellCapacity(lock, typeScript *types.Script) uint64 {
+	capacity := uint64(14200000000)
+	if occupied := (&types.CellOutput{Lock: lock, Type: typeScript}).OccupiedCapacity(make([]byte, 16)) * 100000000; occupied > capacity {
+		capacity = occupied
+	}
+	return capacity
+}
+
+var maxSudtAmount = big.NewInt(0).Sub(big.NewInt(0).Lsh(big.NewInt(1), 128), big.NewInt(1))
+
+// UpdateSudtAmount returns a copy of sUDT cell data with the uint128 amount replaced.
+// Bytes after the first 16 are extension data and are kept verbatim.
+func UpdateSudtAmount(data []byte, amount *big.Int) ([]byte, error) {
+	if len(data) < 16 {
+		return nil, errors.New("invalid sUDT amount")
+	}
+	if amount.Sign() < 0 || amount.Cmp(maxSudtAmount) > 0 {
+		return nil, fmt.Errorf("sUDT amount out of range: %s", amount.String())
+	}
+	result := make([]byte, 0, len(data))
+	result = append(result, utils.GenerateSudtAmount(amount)...)
+	return append(result, data[16:]...), nil
+}
+
+// GroupLiveCellsByLock splits cells into lock script groups, keeping the order cells were first seen.
+func GroupLiveCellsByLock(cells []*indexer.LiveCell) [][]*indexer.LiveCell {
+	var groups [][]*indexer.LiveCell
+	index := make(map[types.Hash]int)
+	for _, cell := range cells {
+		hash, _ := cell.Output.Lock.Hash()
+		i, ok := index[hash]
+		if !ok {
+			i = len(groups)
+			index[hash] = i
+			groups = append(groups, nil)
+		}
+		groups[i] = append(groups[i], cell)
+	}
+	return groups
+}
+
+// ResolveInputs returns the previous output of every input of tx, nil for cellbase inputs.
+func ResolveInputs(client rpc.Client, tx *types.Transaction) ([]*indexer.LiveCell, error) {
+	cells := make([]*indexer.LiveCell, len(tx.Inputs))
+	txs := make(map[types.Hash]*types.Transaction)
+	for i, input := range tx.Inputs {
+		outPoint := input.PreviousOutput
+		if outPoint.TxHash == (types.Hash{}) {
+			continue
+		}
+		previous, ok := txs[outPoint.TxHash]
+		if !ok {
+			result, err := client.GetTransaction(context.Background(), outPoint.TxHash)
+			if err != nil {
+				return nil, err
+			}
+			previous = result.Transaction
+			txs[outPoint.TxHash] = previous
+		}
+		if previous == nil || int(outPoint.Index) >= len(previous.Outputs) {
+			return nil, fmt.Errorf("can't resolve input %s", OutPointString(outPoint))
+		}
+		cells[i] = &indexer.LiveCell{
+			OutPoint:   outPoint,
+			Output:     previous.Outputs[outPoint.Index],
+			OutputData: previous.OutputsData[outPoint.Index],
+		}
+	}
+	return cells, nil
+}
@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+)
+
+func TestUpdateSudtAmount(t *testing.T) {
+	amount := func(b ...byte) []byte {
+		data := make([]byte, 16)
+		copy(data, b)
+		return data
+	}
+	overflow := new(big.Int).Lsh(big.NewInt(1), 128)
+
+	tests := []struct {
+		name    string
+		data    []byte
+		amount  *big.Int
+		want    []byte
+		wantErr bool
+	}{
+		{
+			name:   "amount only",
+			data:   amount(0x01),
+			amount: big.NewInt(0x0302),
+			want:   amount(0x02, 0x03),
+		},
+		{
+			name:   "extension data kept verbatim",
+			data:   append(amount(0x05), 0xde, 0xad, 0xbe, 0xef),
+			amount: big.NewInt(7),
+			want:   append(amount(0x07), 0xde, 0xad, 0xbe, 0xef),
+		},
+		{
+			name:   "max uint128",
+			data:   amount(),
+			amount: maxSudtAmount,
+			want:   bytes.Repeat([]byte{0xff}, 16),
+		},
+		{
+			name:    "data shorter than 16 bytes",
+			data:    make([]byte, 15),
+			amount:  big.NewInt(1),
+			wantErr: true,
+		},
+		{
+			name:    "amount above uint128",
+			data:    amount(),
+			amount:  overflow,
+			wantErr: true,
+		},
+		{
+			name:    "negative amount",
+			data:    amount(),
+			amount:  big.NewInt(-1),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			origin := append([]byte(nil), tt.data...)
+			got, err := UpdateSudtAmount(tt.data, tt.amount)
+			if !bytes.Equal(tt.data, origin) {
+				t.Fatalf("input modified: %x, was %x", tt.data, origin)
+			}
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got %x", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !bytes.Equal(got, tt.want) {
+				t.Fatalf("got %x, want %x", got, tt.want)
+			}
+			parsed, err := ParseUDTAmount(got)
+			if err != nil || parsed.Cmp(tt.amount) != 0 {
+				t.Fatalf("parsed amount %v, %v, want %s", parsed, err, tt.amount)
+			}
+		})
+	}
+}
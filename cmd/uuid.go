@@ -0,0 +1,139 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/nervosnetwork/ckb-sdk-go/address"
+	"github.com/nervosnetwork/ckb-sdk-go/indexer"
+	"github.com/nervosnetwork/ckb-sdk-go/types"
+	"github.com/nervosnetwork/ckb-sdk-go/utils"
+	"github.com/ququzone/ckb-udt-cli/config"
+	"github.com/spf13/cobra"
+)
+
+const uuidOwnerPageSize = 100
+
+var (
+	uuidOwnerConf *string
+)
+
+var uuidCmd = &cobra.Command{
+	Use:   "uuid",
+	Short: "sUDT uuid utilities",
+	Long:  `Convert between sUDT uuids and the owner addresses they are derived from.`,
+}
+
+var uuidFromAddressCmd = &cobra.Command{
+	Use:   "from-address ADDRESS",
+	Short: "Compute the uuid of sUDT issued by an address",
+	Long:  `Compute the uuid of sUDT issued by an address, which is the hash of its lock script.`,
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		addr, err := address.Parse(args[0])
+		if err != nil {
+			Fatalf("parse address error: %v", err)
+		}
+		uuid, err := addr.Script.Hash()
+		if err != nil {
+			Fatalf("compute lock hash error: %v", err)
+		}
+		fmt.Printf("uuid: %s\n", uuid.String())
+	},
+}
+
+var uuidOwnerCmd = &cobra.Command{
+	Use:   "owner UUID",
+	Short: "Find the owner address of a uuid",
+	Long:  `Search the transactions of a sUDT for the secp256k1 or anyone can pay lock whose hash is the uuid and print its address.`,
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		c, err := config.Init(*uuidOwnerConf)
+		if err != nil {
+			Fatalf("load config error: %v", err)
+		}
+
+		client, err := Dial(c)
+		if err != nil {
+			Fatalf("create rpc client error: %v", err)
+		}
+
+		scripts, err := utils.NewSystemScripts(client)
+		if err != nil {
+			Fatalf("load system script error: %v", err)
+		}
+
+		uuid := types.HexToHash(args[0])
+		acpCodeHash := types.HexToHash(c.ACP.Script.CodeHash)
+		// owner reports whether lock is a secp256k1 or anyone can pay lock hashing to uuid
+		owner := func(lock *types.Script) bool {
+			if lock == nil || (lock.CodeHash != scripts.SecpSingleSigCell.CellHash && lock.CodeHash != acpCodeHash) {
+				return false
+			}
+			hash, err := lock.Hash()
+			return err == nil && hash == uuid
+		}
+
+		// the owner lock is an input of every transaction minting the token
+		searchKey := &indexer.SearchKey{
+			Script:     UDTTypeScript(c, uuid.Bytes()),
+			ScriptType: indexer.ScriptTypeType,
+		}
+		seen := make(map[types.Hash]bool)
+		cursor := ""
+		for {
+			txs, err := client.GetTransactions(context.Background(), searchKey, indexer.SearchOrderAsc, uuidOwnerPageSize, cursor)
+			if err != nil {
+				Fatalf("query transactions error: %v", err)
+			}
+			for _, item := range txs.Objects {
+				if seen[item.TxHash] {
+					continue
+				}
+				seen[item.TxHash] = true
+
+				tx, err := client.GetTransaction(context.Background(), item.TxHash)
+				if err != nil {
+					Fatalf("query transaction %s error: %v", item.TxHash.String(), err)
+				}
+				inputs, err := ResolveInputs(client, tx.Transaction)
+				if err != nil {
+					Fatalf("resolve transaction %s error: %v", item.TxHash.String(), err)
+				}
+				locks := make([]*types.Script, 0, len(inputs)+len(tx.Transaction.Outputs))
+				for _, input := range inputs {
+					if input != nil {
+						locks = append(locks, input.Output.Lock)
+					}
+				}
+				for _, output := range tx.Transaction.Outputs {
+					locks = append(locks, output.Lock)
+				}
+				for _, lock := range locks {
+					if !owner(lock) {
+						continue
+					}
+					addr, err := address.Generate(address.Testnet, lock)
+					if err != nil {
+						Fatalf("generate address error: %v", err)
+					}
+					fmt.Printf("owner address: %s, found in transaction %s\n", addr, item.TxHash.String())
+					return
+				}
+			}
+			if len(txs.Objects) < uuidOwnerPageSize || txs.LastCursor == "" {
+				break
+			}
+			cursor = txs.LastCursor
+		}
+		Fatalf("no secp256k1 or anyone can pay lock with hash %s found in %d transactions of the sUDT", uuid.String(), len(seen))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(uuidCmd)
+	uuidCmd.AddCommand(uuidFromAddressCmd)
+	uuidCmd.AddCommand(uuidOwnerCmd)
+
+	uuidOwnerConf = uuidOwnerCmd.Flags().StringP("config", "c", "config.yaml", "Config file")
+}
@@ -0,0 +1,159 @@
+package cmd
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/nervosnetwork/ckb-sdk-go/crypto/blake2b"
+	"github.com/nervosnetwork/ckb-sdk-go/indexer"
+	"github.com/nervosnetwork/ckb-sdk-go/rpc"
+	"github.com/nervosnetwork/ckb-sdk-go/types"
+	"github.com/ququzone/ckb-udt-cli/config"
+)
+
+// ValidateTransaction checks a built transaction locally before it is signed: sUDT amounts are
+// conserved unless the owner lock is an input, every output holds its occupied capacity, the fee
+// is positive and at most c.MaxFee, and the cell deps provide every lock and type script.
+func ValidateTransaction(client rpc.Client, c *config.Config, tx *types.Transaction) error {
+	inputs, err := ResolveInputs(client, tx)
+	if err != nil {
+		return err
+	}
+	summary, err := Summarize(c, tx, inputs)
+	if err != nil {
+		return err
+	}
+
+	for _, u := range summary.UDTs {
+		if u.Output.Cmp(u.Input) > 0 && !u.Owner {
+			return fmt.Errorf("sUDT %s outputs %s exceed inputs %s without owner lock input", u.UUID, u.Output.String(), u.Input.String())
+		}
+	}
+
+	for i, output := range tx.Outputs {
+		occupied := output.OccupiedCapacity(tx.OutputsData[i]) * 100000000
+		if output.Capacity < occupied {
+			return fmt.Errorf("output %d capacity %d is less than occupied capacity %d", i, output.Capacity, occupied)
+		}
+	}
+
+	if summary.InputCapacity <= summary.OutputCapacity {
+		return fmt.Errorf("fee must be positive, inputs capacity %d outputs capacity %d", summary.InputCapacity, summary.OutputCapacity)
+	}
+	fee := summary.InputCapacity - summary.OutputCapacity
+	if fee > c.MaxFee {
+		return fmt.Errorf("fee %d exceeds maximum %d", fee, c.MaxFee)
+	}
+
+	return checkCellDeps(client, tx, inputs)
+}
+
+// checkCellDeps verifies that every script used by tx is provided by a cell dep, by data hash or type hash.
+func checkCellDeps(client rpc.Client, tx *types.Transaction, inputs []*indexer.LiveCell) error {
+	resolver := &depResolver{client: client, txs: make(map[types.Hash]*types.Transaction)}
+	dataHashes := make(map[types.Hash]bool)
+	typeHashes := make(map[types.Hash]bool)
+	provide := func(outPoint *types.OutPoint) ([]byte, error) {
+		output, data, err := resolver.cell(outPoint)
+		if err != nil {
+			return nil, err
+		}
+		hash, err := blake2b.Blake256(data)
+		if err != nil {
+			return nil, err
+		}
+		dataHashes[types.BytesToHash(hash)] = true
+		if output.Type != nil {
+			typeHash, err := output.Type.Hash()
+			if err != nil {
+				return nil, err
+			}
+			typeHashes[typeHash] = true
+		}
+		return data, nil
+	}
+	for _, dep := range tx.CellDeps {
+		data, err := provide(dep.OutPoint)
+		if err != nil {
+			return fmt.Errorf("resolve cell dep %s error: %v", OutPointString(dep.OutPoint), err)
+		}
+		if dep.DepType != types.DepTypeDepGroup {
+			continue
+		}
+		outPoints, err := parseOutPointVec(data)
+		if err != nil {
+			return fmt.Errorf("parse dep group %s error: %v", OutPointString(dep.OutPoint), err)
+		}
+		for _, outPoint := range outPoints {
+			if _, err := provide(outPoint); err != nil {
+				return fmt.Errorf("resolve dep group %s member %s error: %v", OutPointString(dep.OutPoint), OutPointString(outPoint), err)
+			}
+		}
+	}
+
+	var scripts []*types.Script
+	for _, cell := range inputs {
+		if cell != nil {
+			scripts = append(scripts, cell.Output.Lock, cell.Output.Type)
+		}
+	}
+	for _, output := range tx.Outputs {
+		scripts = append(scripts, output.Type)
+	}
+	for _, script := range scripts {
+		if script == nil {
+			continue
+		}
+		provided := dataHashes[script.CodeHash]
+		if script.HashType == types.HashTypeType {
+			provided = typeHashes[script.CodeHash]
+		}
+		if !provided {
+			return fmt.Errorf("no cell dep provides script code hash %s with hash type %s", script.CodeHash.String(), script.HashType)
+		}
+	}
+	return nil
+}
+
+type depResolver struct {
+	client rpc.Client
+	txs    map[types.Hash]*types.Transaction
+}
+
+func (r *depResolver) cell(outPoint *types.OutPoint) (*types.CellOutput, []byte, error) {
+	tx, ok := r.txs[outPoint.TxHash]
+	if !ok {
+		result, err := r.client.GetTransaction(context.Background(), outPoint.TxHash)
+		if err != nil {
+			return nil, nil, err
+		}
+		tx = result.Transaction
+		r.txs[outPoint.TxHash] = tx
+	}
+	if tx == nil || int(outPoint.Index) >= len(tx.Outputs) {
+		return nil, nil, errors.New("cell not found")
+	}
+	return tx.Outputs[outPoint.Index], tx.OutputsData[outPoint.Index], nil
+}
+
+// parseOutPointVec decodes dep group cell data, a molecule fixvec of 36 byte outpoints.
+func parseOutPointVec(data []byte) ([]*types.OutPoint, error) {
+	if len(data) < 4 {
+		return nil, errors.New("invalid outpoint vector")
+	}
+	count := binary.LittleEndian.Uint32(data)
+	if uint64(len(data)) != 4+uint64(count)*36 {
+		return nil, errors.New("invalid outpoint vector length")
+	}
+	var result []*types.OutPoint
+	for i := uint32(0); i < count; i++ {
+		b := data[4+i*36 : 4+(i+1)*36]
+		result = append(result, &types.OutPoint{
+			TxHash: types.BytesToHash(b[:32]),
+			Index:  uint(binary.LittleEndian.Uint32(b[32:])),
+		})
+	}
+	return result, nil
+}
@@ -0,0 +1,132 @@
+package cmd
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/nervosnetwork/ckb-sdk-go/types"
+	"github.com/nervosnetwork/ckb-sdk-go/utils"
+	"github.com/ququzone/ckb-udt-cli/config"
+)
+
+const (
+	StandardSUDT = "sudt"
+	StandardXUDT = "xudt"
+
+	// xudtFlagExtensions marks xUDT args carrying the extension scripts inline
+	xudtFlagExtensions uint32 = 1
+	// xudtFlagExtensionsHash marks xUDT args carrying the hash of extension scripts provided in witnesses
+	xudtFlagExtensionsHash uint32 = 2
+	// xudtOwnerModeMask covers the bits changing how the owner is recognized
+	xudtOwnerModeMask uint32 = 0xe0000000
+)
+
+// ParseUDTAmount returns the amount of sUDT or xUDT cell data, the uint128 in its first 16 bytes.
+// The bytes after it are xUDT extension data and are ignored.
+func ParseUDTAmount(data []byte) (*big.Int, error) {
+	return utils.ParseSudtAmount(data)
+}
+
+// ParseUUID decodes the type script args of a token. A sUDT uuid is a 32 byte lock hash, while xUDT args
+// may append flags and extension scripts to it.
+func ParseUUID(s string) []byte {
+	b, err := hex.DecodeString(strings.TrimPrefix(s, "0x"))
+	if err != nil || len(b) <= 32 {
+		return types.HexToHash(s).Bytes()
+	}
+	return b
+}
+
+// IsXUDTScript reports whether script is the configured xUDT type script.
+func IsXUDTScript(c *config.Config, script *types.Script) bool {
+	return script != nil && c.XUDT.Script.CodeHash != "" &&
+		script.CodeHash == types.HexToHash(c.XUDT.Script.CodeHash) &&
+		script.HashType == types.ScriptHashType(c.XUDT.Script.HashType)
+}
+
+// ParseScript parses CODE_HASH:HASH_TYPE:ARGS into a script.
+func ParseScript(s string) (*types.Script, error) {
+	parts := strings.Split(s, ":")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("invalid script %s, expect CODE_HASH:HASH_TYPE:ARGS", s)
+	}
+	hashType := types.ScriptHashType(parts[1])
+	if hashType != types.HashTypeData && hashType != types.HashTypeType {
+		return nil, fmt.Errorf("invalid hash type %s, expect data or type", parts[1])
+	}
+	args, err := hex.DecodeString(strings.TrimPrefix(parts[2], "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid script args %s: %v", parts[2], err)
+	}
+	return &types.Script{
+		CodeHash: types.HexToHash(parts[0]),
+		HashType: hashType,
+		Args:     args,
+	}, nil
+}
+
+// serializeScriptVec encodes scripts as a molecule ScriptVec.
+func serializeScriptVec(scripts []*types.Script) ([]byte, error) {
+	items := make([][]byte, 0, len(scripts))
+	size := 4 + 4*len(scripts)
+	for _, script := range scripts {
+		item, err := script.Serialize()
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+		size += len(item)
+	}
+	result := make([]byte, 4+4*len(items), size)
+	binary.LittleEndian.PutUint32(result, uint32(size))
+	offset := 4 + 4*len(items)
+	for i, item := range items {
+		binary.LittleEndian.PutUint32(result[4+4*i:], uint32(offset))
+		offset += len(item)
+	}
+	for _, item := range items {
+		result = append(result, item...)
+	}
+	return result, nil
+}
+
+// hasCellDep reports whether deps already include outPoint.
+func hasCellDep(deps []*types.CellDep, outPoint *types.OutPoint) bool {
+	for _, dep := range deps {
+		if dep.OutPoint.TxHash == outPoint.TxHash && dep.OutPoint.Index == outPoint.Index {
+			return true
+		}
+	}
+	return false
+}
+
+// XUDTArgs returns the xUDT type script args of owner lock hash. Without flags the args are the lock hash
+// only, with xudtFlagExtensions the extension scripts follow the flags. Other flags, including the owner
+// mode bits, are rejected.
+func XUDTArgs(owner types.Hash, flags uint32, extensions []*types.Script) ([]byte, error) {
+	switch {
+	case flags&xudtOwnerModeMask != 0:
+		return nil, fmt.Errorf("xUDT owner mode flags %#x are not supported", flags&xudtOwnerModeMask)
+	case flags == 0 && len(extensions) > 0:
+		flags = xudtFlagExtensions
+	case flags == xudtFlagExtensionsHash:
+		return nil, errors.New("xUDT flags 2 keep extension scripts in witnesses and are not supported, use flags 1")
+	case flags != 0 && flags != xudtFlagExtensions:
+		return nil, fmt.Errorf("unsupported xUDT flags %d, expect 0 or 1", flags)
+	}
+	args := owner.Bytes()
+	if flags == 0 {
+		return args, nil
+	}
+	args = append(args, 0, 0, 0, 0)
+	binary.LittleEndian.PutUint32(args[32:], flags)
+	vec, err := serializeScriptVec(extensions)
+	if err != nil {
+		return nil, err
+	}
+	return append(args, vec...), nil
+}
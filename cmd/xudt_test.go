@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/nervosnetwork/ckb-sdk-go/types"
+)
+
+func TestSerializeScriptVec(t *testing.T) {
+	script := &types.Script{
+		CodeHash: types.HexToHash("0x0fd98e76d8a6d4cc23ac0d3b4a8b9c8d5b3a7c5e8f0e1d2c3b4a59687766554d"),
+		HashType: types.HashTypeData,
+		Args:     []byte{0x12, 0x34},
+	}
+	// Script table: total size 55, field offsets 16, 48 and 49, then code hash, hash type and args
+	item := "37000000" + "10000000" + "30000000" + "31000000" +
+		"0fd98e76d8a6d4cc23ac0d3b4a8b9c8d5b3a7c5e8f0e1d2c3b4a59687766554d" + "00" + "02000000" + "1234"
+	tests := []struct {
+		name    string
+		scripts []*types.Script
+		want    string
+	}{
+		{"empty", nil, "04000000"},
+		{"one script", []*types.Script{script}, "3f000000" + "08000000" + item},
+		{"two scripts", []*types.Script{script, script}, "7a000000" + "0c000000" + "43000000" + item + item},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := serializeScriptVec(tt.scripts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if hex.EncodeToString(got) != tt.want {
+				t.Fatalf("got %x, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestXUDTArgs(t *testing.T) {
+	owner := types.HexToHash("0xce6d56bced68d200dc15799378ef8479fefd3243e683253b8b8b672ffde52ac2")
+	extension := &types.Script{
+		CodeHash: types.HexToHash("0x0fd98e76d8a6d4cc23ac0d3b4a8b9c8d5b3a7c5e8f0e1d2c3b4a59687766554d"),
+		HashType: types.HashTypeData,
+		Args:     []byte{0x12, 0x34},
+	}
+	vec := "3f000000" + "08000000" + "37000000" + "10000000" + "30000000" + "31000000" +
+		"0fd98e76d8a6d4cc23ac0d3b4a8b9c8d5b3a7c5e8f0e1d2c3b4a59687766554d" + "00" + "02000000" + "1234"
+	ownerHex := strings.TrimPrefix(owner.String(), "0x")
+	tests := []struct {
+		name       string
+		flags      uint32
+		extensions []*types.Script
+		want       string
+		wantErr    bool
+	}{
+		{name: "no flags", want: ownerHex},
+		{name: "extensions imply flags 1", extensions: []*types.Script{extension}, want: ownerHex + "01000000" + vec},
+		{name: "flags 1", flags: 1, extensions: []*types.Script{extension}, want: ownerHex + "01000000" + vec},
+		{name: "flags 1 without extensions", flags: 1, want: ownerHex + "01000000" + "04000000"},
+		{name: "flags 2", flags: 2, extensions: []*types.Script{extension}, wantErr: true},
+		{name: "owner mode bit", flags: 0x80000000, wantErr: true},
+		{name: "owner mode with extensions", flags: 0x80000001, extensions: []*types.Script{extension}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := XUDTArgs(owner, tt.flags, tt.extensions)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got %x", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			want, _ := hex.DecodeString(tt.want)
+			if !bytes.Equal(got, want) {
+				t.Fatalf("got %x, want %s", got, tt.want)
+			}
+		})
+	}
+}
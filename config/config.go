@@ -1,6 +1,8 @@
 package config
 
 import (
+	"errors"
+	"fmt"
 	"io/ioutil"
 
 	"gopkg.in/yaml.v2"
@@ -9,7 +11,20 @@ import (
 type Config struct {
 	RPC        string `yaml:"rpc"`
 	CkbIndexer string `yaml:"ckbIndexer"`
-	UDT        struct {
+	// RPCTimeout bounds each RPC call, RPCRetries is how many times failed queries are retried.
+	RPCTimeout string `yaml:"rpcTimeout"`
+	RPCRetries int    `yaml:"rpcRetries"`
+	// MaxFee is the highest fee in shannons a built transaction may pay.
+	MaxFee uint64 `yaml:"maxFee"`
+	// Reservations is the outpoint reservation file shared by concurrent invocations.
+	Reservations string `yaml:"reservations"`
+	// Supply lists treasury and custody addresses whose sUDT is not counted as circulating.
+	Supply struct {
+		Treasury []string `yaml:"treasury"`
+		Custody  []string `yaml:"custody"`
+	} `yaml:"supply"`
+	// UDT is the token type script commands operate on, sUDT unless SelectStandard picks xUDT.
+	UDT struct {
 		Deps []struct {
 			TxHash  string `yaml:"txHash"`
 			Index   uint   `yaml:"index"`
@@ -21,6 +36,25 @@ type Config struct {
 		} `yaml:"script"`
 	} `yaml:"udt"`
 
+	// XUDT is the extensible UDT type script, optional. ExtensionDeps hold the code of extension
+	// scripts and are added to every xUDT transaction, so tokens with extensions can be moved.
+	XUDT struct {
+		Deps []struct {
+			TxHash  string `yaml:"txHash"`
+			Index   uint   `yaml:"index"`
+			DepType string `yaml:"depType"`
+		} `yaml:"deps"`
+		ExtensionDeps []struct {
+			TxHash  string `yaml:"txHash"`
+			Index   uint   `yaml:"index"`
+			DepType string `yaml:"depType"`
+		} `yaml:"extensionDeps"`
+		Script struct {
+			CodeHash string `yaml:"codeHash"`
+			HashType string `yaml:"hashType"`
+		} `yaml:"script"`
+	} `yaml:"xudt"`
+
 	ACP struct {
 		Deps []struct {
 			TxHash  string `yaml:"txHash"`
@@ -32,10 +66,27 @@ type Config struct {
 			HashType string `yaml:"hashType"`
 		} `yaml:"script"`
 	} `yaml:"acp"`
+
+	// Info is the type script of sUDT info cells, optional.
+	Info struct {
+		Deps []struct {
+			TxHash  string `yaml:"txHash"`
+			Index   uint   `yaml:"index"`
+			DepType string `yaml:"depType"`
+		} `yaml:"deps"`
+		Script struct {
+			CodeHash string `yaml:"codeHash"`
+			HashType string `yaml:"hashType"`
+		} `yaml:"script"`
+	} `yaml:"info"`
 }
 
 func Init(path string) (*Config, error) {
-	var c Config
+	c := Config{
+		RPCTimeout: "30s",
+		RPCRetries: 3,
+		MaxFee:     100000000,
+	}
 
 	file, err := ioutil.ReadFile(path)
 	if err != nil {
@@ -48,3 +99,19 @@ func Init(path string) (*Config, error) {
 
 	return &c, nil
 }
+
+// SelectStandard makes UDT describe the token standard, sudt or xudt.
+func (c *Config) SelectStandard(standard string) error {
+	switch standard {
+	case "sudt":
+	case "xudt":
+		if c.XUDT.Script.CodeHash == "" {
+			return errors.New("xudt script is not configured")
+		}
+		c.UDT.Deps = append(c.XUDT.Deps, c.XUDT.ExtensionDeps...)
+		c.UDT.Script = c.XUDT.Script
+	default:
+		return fmt.Errorf("unknown token standard %s, expect sudt or xudt", standard)
+	}
+	return nil
+}
@@ -1,16 +1,18 @@
 module github.com/ququzone/ckb-udt-cli
 
-go 1.12
+go 1.13
 
 require (
 	github.com/aristanetworks/goarista v0.0.0-20200521140103-6c3304613b30 // indirect
 	github.com/deckarep/golang-set v1.7.1 // indirect
 	github.com/elastic/gosigar v0.10.5 // indirect
+	github.com/ethereum/go-ethereum v1.9.14
 	github.com/gorilla/websocket v1.4.2 // indirect
 	github.com/nervosnetwork/ckb-sdk-go v0.2.1
 	github.com/spf13/cobra v1.0.0
-	github.com/spf13/pflag v1.0.5 // indirect
-	golang.org/x/crypto v0.0.0-20200510223506-06a226fb4e37 // indirect
-	golang.org/x/sys v0.0.0-20200523222454-059865788121 // indirect
+	github.com/spf13/pflag v1.0.5
+	github.com/tyler-smith/go-bip39 v1.0.2
+	golang.org/x/crypto v0.0.0-20200510223506-06a226fb4e37
+	golang.org/x/sys v0.0.0-20200523222454-059865788121
 	gopkg.in/yaml.v2 v2.2.8
 )